@@ -0,0 +1,73 @@
+package v1
+
+import (
+	netv1 "k8s.io/api/networking/v1"
+)
+
+// ConvertFromNetworking converts a core networking.k8s.io/v1 Ingress into the internal Ingress model so that
+// it can go through the same annotation extraction and templates as the custom resource.
+// Backends that reference a resource instead of a Service are dropped, they can't be rendered into an upstream.
+func ConvertFromNetworking(in *netv1.Ingress) *Ingress {
+	out := &Ingress{
+		TypeMeta: in.TypeMeta,
+	}
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+
+	if in.Spec.IngressClassName != nil {
+		out.Spec.IngressClassName = *in.Spec.IngressClassName
+	}
+
+	if in.Spec.DefaultBackend != nil && in.Spec.DefaultBackend.Service != nil {
+		out.Spec.DefaultBackend = &IngressBackend{
+			Service: convertServiceBackend(in.Spec.DefaultBackend.Service),
+		}
+	}
+
+	for _, tls := range in.Spec.TLS {
+		out.Spec.TLS = append(out.Spec.TLS, *tls.DeepCopy())
+	}
+
+	for _, rule := range in.Spec.Rules {
+		r := IngressRule{
+			Host: rule.Host,
+			IngressRuleValue: IngressRuleValue{
+				HTTP: &HTTPIngressRuleValue{},
+			},
+		}
+
+		if rule.HTTP != nil {
+			for _, p := range rule.HTTP.Paths {
+				if p.Backend.Service == nil {
+					continue
+				}
+
+				hp := HTTPIngressPath{
+					Path: p.Path,
+					Backend: IngressBackend{
+						Service: convertServiceBackend(p.Backend.Service),
+					},
+				}
+				if p.PathType != nil {
+					pt := PathType(*p.PathType)
+					hp.PathType = &pt
+				}
+
+				r.HTTP.Paths = append(r.HTTP.Paths, hp)
+			}
+		}
+
+		out.Spec.Rules = append(out.Spec.Rules, r)
+	}
+
+	return out
+}
+
+func convertServiceBackend(in *netv1.IngressServiceBackend) *IngressServiceBackend {
+	return &IngressServiceBackend{
+		Name: in.Name,
+		Port: ServiceBackendPort{
+			Name:   in.Port.Name,
+			Number: in.Port.Number,
+		},
+	}
+}
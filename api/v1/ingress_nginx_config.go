@@ -1,27 +1,40 @@
 package v1
 
+//+kubebuilder:object:generate=false
+
 type ParseIngressAnnotations interface {
 	GetIngressAnnotations()
 }
 
+//+kubebuilder:object:generate=false
+
 type Configuration struct {
 	Servers []*Server `json:"servers"`
+	// Upstreams are shared by the locations of several servers, e.g. a HTTPRoute attached to more than one listener
+	Upstreams []*Upstream `json:"upstreams,omitempty"`
 }
 
+//+kubebuilder:object:generate=false
+
 type Server struct {
 	Name      string     `json:"name"`
 	NameSpace string     `json:"name_space"`
 	HostName  string     `json:"host_name"`
 	Tls       SSLCert    `json:"tls"`
 	Paths     []*Backend `json:"paths"`
+	Port      int32      `json:"port,omitempty"`
 }
 
+//+kubebuilder:object:generate=false
+
 type SSLCert struct {
 	TlsKey    string `json:"tls-key"`
 	TlsCrt    string `json:"tls-crt"`
 	TlsNoPass bool   `json:"tls-no-pass"`
 }
 
+//+kubebuilder:object:generate=false
+
 type Backend struct {
 	Name           string                  `json:"name"`
 	IngName        string                  `json:"ing_name"`
@@ -33,4 +46,96 @@ type Backend struct {
 	Annotations    ParseIngressAnnotations `json:"annotations"`
 	RewritePath    string                  `json:"rewrite_path"`
 	UpstreamName   string                  `json:"upstream_name"`
+	Matches        []*RouteMatch           `json:"matches,omitempty"`
+	// Endpoints replace the Service address in the upstream when the backend is health checked
+	Endpoints []Endpoint `json:"endpoints,omitempty"`
+}
+
+//+kubebuilder:object:generate=false
+
+// Endpoint is a ready address of the Service of a backend, Down when it fails its health checks.
+type Endpoint struct {
+	Address string `json:"address"`
+	Down    bool   `json:"down"`
+}
+
+//+kubebuilder:object:generate=false
+
+type Upstream struct {
+	Name    string           `json:"name"`
+	Servers []UpstreamServer `json:"servers"`
+}
+
+//+kubebuilder:object:generate=false
+
+type UpstreamServer struct {
+	Address string `json:"address"`
+	Weight  int32  `json:"weight"`
+}
+
+//+kubebuilder:object:generate=false
+
+// RouteMatch is a request match rendered inside the location of its path. A match with conditions is
+// evaluated in order and jumps to an internal location named after it, the first match without conditions
+// is served by the location itself. Return, if set, answers the request instead of proxying it, e.g. a redirect.
+type RouteMatch struct {
+	Name         string           `json:"name"`
+	Conditions   []RouteCondition `json:"conditions,omitempty"`
+	Expect       string           `json:"expect,omitempty"`
+	UpstreamName string           `json:"upstream_name,omitempty"`
+	SetHeaders   []Header         `json:"set_headers,omitempty"`
+	HostRewrite  string           `json:"host_rewrite,omitempty"`
+	RewriteFrom  string           `json:"rewrite_from,omitempty"`
+	RewriteTo    string           `json:"rewrite_to,omitempty"`
+	Return       string           `json:"return,omitempty"`
+}
+
+//+kubebuilder:object:generate=false
+
+// RouteCondition renders to `if ($Variable Operator "Value")`
+type RouteCondition struct {
+	Variable string `json:"variable"`
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+}
+
+//+kubebuilder:object:generate=false
+
+type Header struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+//+kubebuilder:object:generate=false
+
+// StreamConfiguration is rendered into the stream {} context.
+type StreamConfiguration struct {
+	Upstreams []*Upstream     `json:"upstreams"`
+	Servers   []*StreamServer `json:"servers"`
+}
+
+//+kubebuilder:object:generate=false
+
+// StreamServer is a single listen port, proxied either to UpstreamName or, when Routes or DefaultUpstream is set,
+// to the upstream picked by the SNI of the client hello with the TLS stream passed through.
+type StreamServer struct {
+	Name string `json:"name"`
+	// Address binds the server to a local address, it is then only an internal hop
+	Address         string         `json:"address,omitempty"`
+	Port            int32          `json:"port"`
+	UDP             bool           `json:"udp"`
+	Tls             SSLCert        `json:"tls"`
+	UpstreamName    string         `json:"upstream_name,omitempty"`
+	Routes          []*StreamRoute `json:"routes,omitempty"`
+	DefaultUpstream string         `json:"default_upstream,omitempty"`
+	// ProxyProtocol sends the client address to the upstream, AcceptProxyProtocol expects it from the client
+	ProxyProtocol       bool `json:"proxy_protocol"`
+	AcceptProxyProtocol bool `json:"accept_proxy_protocol"`
+}
+
+//+kubebuilder:object:generate=false
+
+type StreamRoute struct {
+	Host         string `json:"host"`
+	UpstreamName string `json:"upstream_name"`
 }
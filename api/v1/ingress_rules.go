@@ -1,5 +1,35 @@
 package v1
 
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// annotationsPrefix is the prefix of the annotations of the controller.
+const annotationsPrefix = "ingress.nginx.kubebuilder.io/"
+
+// pathOverrideAnnotations are the annotations rendered into the locations, the only ones a path override can set.
+var pathOverrideAnnotations = []string{
+	"rewrite-target",
+	"enable-regex",
+	"allowList",
+	"denyList",
+	"permanent-redirect",
+	"permanent-redirect-code",
+	"temporal-redirect",
+	"redirect-preserve-uri",
+	"proxy-set-headers",
+	"hide-upstream-headers",
+	"upstream-vhost",
+	"enable-modsecurity",
+	"enable-owasp-core-rules",
+	"modsecurity-transaction-id",
+	"modsecurity-snippet",
+	"configuration-snippet",
+}
+
 type IngressRule struct {
 	Host             string `json:"host,omitempty" protobuf:"bytes,1,opt,name=host"`
 	IngressRuleValue `json:",inline,omitempty" protobuf:"bytes,2,opt,name=ingressRuleValue"`
@@ -15,12 +45,66 @@ type HTTPIngressRuleValue struct {
 
 type PathType string
 
+const (
+	// PathTypeExact matches the URL path exactly.
+	PathTypeExact PathType = "Exact"
+	// PathTypePrefix matches the URL path element by element, /foo matches /foo and /foo/bar but not /foobar.
+	PathTypePrefix PathType = "Prefix"
+	// PathTypeImplementationSpecific is a regex with the enable-regex or rewrite-target annotations and an nginx
+	// prefix location otherwise.
+	PathTypeImplementationSpecific PathType = "ImplementationSpecific"
+)
+
 type HTTPIngressPath struct {
 	Path     string         `json:"path,omitempty" protobuf:"bytes,1,opt,name=path"`
 	PathType *PathType      `json:"pathType" protobuf:"bytes,3,opt,name=pathType"`
 	Backend  IngressBackend `json:"backend" protobuf:"bytes,2,opt,name=backend"`
 }
 
+// PathOverride sets the annotations of the locations of Paths, e.g. rewrite-target on /api only. Only the
+// annotations rendered into the locations can be set, the others stay those of the ingress.
+type PathOverride struct {
+	// Host of the rule of Paths, the rules of every host when it is empty
+	// +optional
+	Host string `json:"host,omitempty"`
+	// Paths are paths of the rules as written in the ingress
+	Paths []string `json:"paths"`
+	// Annotations are set like those of the ingress, e.g. ingress.nginx.kubebuilder.io/rewrite-target: /$1
+	Annotations map[string]string `json:"annotations"`
+}
+
+// CheckAnnotations checks that the override only sets annotations rendered into the locations.
+func (o PathOverride) CheckAnnotations() error {
+	for _, name := range slices.Sorted(maps.Keys(o.Annotations)) {
+		suffix, ok := strings.CutPrefix(name, annotationsPrefix)
+		if !ok || !slices.Contains(pathOverrideAnnotations, suffix) {
+			return fmt.Errorf("annotation %s can't be set on a path", name)
+		}
+	}
+
+	return nil
+}
+
+// Matches reports whether the override applies to path of the rule of host.
+func (o PathOverride) Matches(host, path string) bool {
+	return (o.Host == "" || o.Host == host) && slices.Contains(o.Paths, path)
+}
+
+// HasPath reports whether path is a path of the rule of host, of any rule when host is empty.
+func (r *Ingress) HasPath(host, path string) bool {
+	for _, rule := range r.Spec.Rules {
+		if rule.HTTP == nil || host != "" && rule.Host != host {
+			continue
+		}
+
+		if slices.ContainsFunc(rule.HTTP.Paths, func(p HTTPIngressPath) bool { return p.Path == path }) {
+			return true
+		}
+	}
+
+	return false
+}
+
 type IngressBackend struct {
 	Service *IngressServiceBackend `json:"service,omitempty" protobuf:"bytes,4,opt,name=service"`
 }
@@ -0,0 +1,30 @@
+package v1
+
+import (
+	"testing"
+)
+
+func TestPathOverrideCheckAnnotations(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		wantErr     bool
+	}{
+		{name: "location annotation", annotations: map[string]string{"ingress.nginx.kubebuilder.io/rewrite-target": "/$1"}},
+		{name: "several location annotations", annotations: map[string]string{
+			"ingress.nginx.kubebuilder.io/enable-modsecurity":    "true",
+			"ingress.nginx.kubebuilder.io/configuration-snippet": "return 200;",
+		}},
+		{name: "server annotation", annotations: map[string]string{"ingress.nginx.kubebuilder.io/ssl-redirect": "false"}, wantErr: true},
+		{name: "other prefix", annotations: map[string]string{"nginx.ingress.kubernetes.io/rewrite-target": "/"}, wantErr: true},
+		{name: "no prefix", annotations: map[string]string{"rewrite-target": "/"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := (PathOverride{Annotations: tt.annotations}).CheckAnnotations(); (err != nil) != tt.wantErr {
+				t.Errorf("CheckAnnotations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -26,8 +26,7 @@ import (
 
 // IngressSpec defines the desired state of Ingress
 type IngressSpec struct {
-	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// IngressClassName is the IngressClass of the controller serving the ingress
 	// +optional
 	IngressClassName string `json:"ingressClassName,omitempty" protobuf:"bytes,4,opt,name=ingressClassName"`
 	// +optional
@@ -37,14 +36,26 @@ type IngressSpec struct {
 	TLS []netv1.IngressTLS `json:"tls,omitempty" protobuf:"bytes,2,rep,name=tls"`
 	// +optional
 	Rules []IngressRule `json:"rules,omitempty" protobuf:"bytes,3,rep,name=rules"`
+	// PathOverrides set annotations on some paths of the rules, they take precedence over the ingress annotations
+	// +optional
+	PathOverrides []PathOverride `json:"pathOverrides,omitempty"`
 }
 
 // IngressStatus defines the observed state of Ingress
 type IngressStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
+
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
+// IngressCertificatesValid is the condition reporting whether the certificate of every tls host is rendered.
+const IngressCertificatesValid = "CertificatesValid"
+
+// IngressModSecurityReady is the condition reporting whether the WAF of enable-modsecurity is rendered.
+const IngressModSecurityReady = "ModSecurityReady"
+
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 
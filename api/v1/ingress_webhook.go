@@ -19,12 +19,13 @@ package v1
 import (
 	"fmt"
 	"k8s.io/apimachinery/pkg/runtime"
-	"regexp"
+	"k8s.io/apimachinery/pkg/util/validation"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 	"strconv"
+	"strings"
 )
 
 const (
@@ -89,10 +90,18 @@ func (r *Ingress) ValidData() error {
 		return err
 	}
 
+	if err := r.ValidHosts(); err != nil {
+		return err
+	}
+
 	if err := r.ValidPathAndHost(); err != nil {
 		return err
 	}
 
+	if err := r.ValidPathOverrides(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -136,15 +145,62 @@ func (r *Ingress) ValidPathAndHost() error {
 	return nil
 }
 
+// ValidPathOverrides checks that every path override sets location annotations on paths of the rules.
+func (r *Ingress) ValidPathOverrides() error {
+	for _, o := range r.Spec.PathOverrides {
+		if len(o.Paths) == 0 || len(o.Annotations) == 0 {
+			return fmt.Errorf("path override of host %q needs paths and annotations in ingress: %s, namespace: %s", o.Host, r.Name, r.Namespace)
+		}
+
+		if err := o.CheckAnnotations(); err != nil {
+			return fmt.Errorf("path override of host %q: %v in ingress: %s, namespace: %s", o.Host, err, r.Name, r.Namespace)
+		}
+
+		for _, path := range o.Paths {
+			if !r.HasPath(o.Host, path) {
+				return fmt.Errorf("path override path: %s matches no path of the rules in ingress: %s, namespace: %s", path, r.Name, r.Namespace)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ValidHost reports whether str is a valid host of a rule, see IsValidHost.
 func (r *Ingress) ValidHost(str string) bool {
-	validHost := func(str string) bool {
-		p := `([a0-z9]+\.)+([a-z]+)`
-		matched := regexp.MustCompile(p)
-		if !matched.MatchString(str) {
-			return false
+	return IsValidHost(str)
+}
+
+// IsValidHost reports whether host is an RFC 1123 host name, a leading wildcard label like *.example.com is allowed.
+func IsValidHost(host string) bool {
+	if strings.HasPrefix(host, "*.") {
+		return len(validation.IsWildcardDNS1123Subdomain(host)) == 0
+	}
+
+	return len(validation.IsDNS1123Subdomain(host)) == 0
+}
+
+// ValidHosts checks the hosts of the rules and the tls section, an empty rule host matches every host.
+// A tls Secret of another namespace is referenced as namespace/name, it needs a SecretGrant in that namespace.
+func (r *Ingress) ValidHosts() error {
+	for _, v := range r.Spec.Rules {
+		if v.Host != "" && !r.ValidHost(v.Host) {
+			return fmt.Errorf("host: %s is an invalid value in ingress: %s, namespace: %s", v.Host, r.Name, r.Namespace)
 		}
-		return true
 	}
 
-	return validHost(str)
+	for _, t := range r.Spec.TLS {
+		ns, name := SecretRef(t.SecretName, r.Namespace)
+		if t.SecretName != "" && (len(validation.IsDNS1123Label(ns)) > 0 || len(validation.IsDNS1123Subdomain(name)) > 0) {
+			return fmt.Errorf("tls secretName: %s is neither name nor namespace/name in ingress: %s, namespace: %s", t.SecretName, r.Name, r.Namespace)
+		}
+
+		for _, h := range t.Hosts {
+			if !r.ValidHost(h) {
+				return fmt.Errorf("tls host: %s is an invalid value in ingress: %s, namespace: %s", h, r.Name, r.Namespace)
+			}
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,96 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"strings"
+)
+
+// SecretGrantSpec defines which namespaces may reference the tls Secrets of the namespace of the SecretGrant
+type SecretGrantSpec struct {
+	// From lists the namespaces whose Ingresses may use the Secrets in spec.tls[].secretName as namespace/name.
+	// +kubebuilder:validation:MinItems=1
+	From []SecretGrantFrom `json:"from"`
+	// SecretNames restricts the grant to these Secrets, every Secret of the namespace is granted when it is empty.
+	// +optional
+	SecretNames []string `json:"secretNames,omitempty"`
+}
+
+type SecretGrantFrom struct {
+	Namespace string `json:"namespace"`
+}
+
+//+kubebuilder:object:root=true
+
+// SecretGrant is the Schema for the secretgrants API, it is the ReferenceGrant of the tls Secrets referenced
+// by Ingresses of other namespaces.
+type SecretGrant struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec SecretGrantSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// SecretGrantList contains a list of SecretGrant
+type SecretGrantList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SecretGrant `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SecretGrant{}, &SecretGrantList{})
+}
+
+// Permits reports whether the grant lets objects of namespace use the Secret secret of the grant namespace.
+func (r *SecretGrant) Permits(namespace, secret string) bool {
+	var from bool
+	for _, f := range r.Spec.From {
+		if f.Namespace == namespace {
+			from = true
+			break
+		}
+	}
+
+	if !from {
+		return false
+	}
+
+	if len(r.Spec.SecretNames) == 0 {
+		return true
+	}
+
+	for _, s := range r.Spec.SecretNames {
+		if s == secret {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SecretRef returns the namespace and the name of a spec.tls[].secretName, which is either name or namespace/name.
+func SecretRef(secretName, namespace string) (string, string) {
+	if ns, name, found := strings.Cut(secretName, "/"); found {
+		return ns, name
+	}
+
+	return namespace, secretName
+}
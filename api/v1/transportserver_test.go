@@ -0,0 +1,82 @@
+package v1
+
+import (
+	"testing"
+)
+
+func transportServer(class string, port int32, protocol TransportProtocol, tls *TransportTLS) *TransportServer {
+	return &TransportServer{Spec: TransportServerSpec{
+		IngressClassName: class,
+		Listener:         TransportListener{Port: port, Protocol: protocol},
+		Upstream:         TransportUpstream{ServiceName: "svc", ServicePort: 80},
+		TLS:              tls,
+	}}
+}
+
+func passthrough(host string) *TransportTLS {
+	return &TransportTLS{Mode: TransportTLSPassthrough, Host: host}
+}
+
+func TestTransportServerConflictsWith(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b *TransportServer
+		want bool
+	}{
+		{name: "same port", a: transportServer("", 5432, "", nil), b: transportServer("", 5432, "", nil), want: true},
+		{name: "different ports", a: transportServer("", 5432, "", nil), b: transportServer("", 5433, "", nil)},
+		{name: "default protocol is tcp", a: transportServer("", 5432, "", nil), b: transportServer("", 5432, TransportProtocolTCP, nil), want: true},
+		{name: "tcp and udp", a: transportServer("", 53, TransportProtocolTCP, nil), b: transportServer("", 53, TransportProtocolUDP, nil)},
+		{name: "different classes", a: transportServer("a", 5432, "", nil), b: transportServer("b", 5432, "", nil)},
+		{name: "distinct sni hosts", a: transportServer("", 8443, "", passthrough("a.example.com")), b: transportServer("", 8443, "", passthrough("b.example.com"))},
+		{name: "same sni host", a: transportServer("", 8443, "", passthrough("a.example.com")), b: transportServer("", 8443, "", passthrough("a.example.com")), want: true},
+		{name: "passthrough without host", a: transportServer("", 8443, "", passthrough("")), b: transportServer("", 8443, "", passthrough("b.example.com")), want: true},
+		{name: "passthrough and plain", a: transportServer("", 8443, "", passthrough("a.example.com")), b: transportServer("", 8443, "", nil), want: true},
+		{
+			name: "passthrough and terminate",
+			a:    transportServer("", 8443, "", passthrough("a.example.com")),
+			b:    transportServer("", 8443, "", &TransportTLS{Mode: TransportTLSTerminate, Host: "b.example.com", SecretName: "tls"}),
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.ConflictsWith(tt.b); got != tt.want {
+				t.Errorf("ConflictsWith() = %v, want %v", got, tt.want)
+			}
+			if got := tt.b.ConflictsWith(tt.a); got != tt.want {
+				t.Errorf("reversed ConflictsWith() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransportServerValidSpec(t *testing.T) {
+	tests := []struct {
+		name    string
+		ts      *TransportServer
+		wantErr bool
+	}{
+		{name: "plain tcp", ts: transportServer("", 5432, "", nil)},
+		{name: "reserved tcp port", ts: transportServer("", 443, "", nil), wantErr: true},
+		{name: "reserved passthrough port", ts: transportServer("", 442, TransportProtocolTCP, nil), wantErr: true},
+		{name: "reserved port over udp", ts: transportServer("", 443, TransportProtocolUDP, nil)},
+		{name: "tls over udp", ts: transportServer("", 8443, TransportProtocolUDP, passthrough("a.example.com")), wantErr: true},
+		{name: "terminate", ts: transportServer("", 8443, "", &TransportTLS{Mode: TransportTLSTerminate, SecretName: "tls"})},
+		{name: "terminate without secret", ts: transportServer("", 8443, "", &TransportTLS{Mode: TransportTLSTerminate}), wantErr: true},
+		{name: "passthrough", ts: transportServer("", 8443, "", passthrough("a.example.com"))},
+		{name: "passthrough without host", ts: transportServer("", 8443, "", passthrough(""))},
+		{name: "passthrough with secret", ts: transportServer("", 8443, "", &TransportTLS{Mode: TransportTLSPassthrough, SecretName: "tls"}), wantErr: true},
+		{name: "passthrough invalid host", ts: transportServer("", 8443, "", passthrough("a.example.com;")), wantErr: true},
+		{name: "unknown mode", ts: transportServer("", 8443, "", &TransportTLS{Mode: "Mutual"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.ts.ValidSpec(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidSpec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
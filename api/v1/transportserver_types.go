@@ -0,0 +1,149 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type TransportProtocol string
+
+const (
+	TransportProtocolTCP TransportProtocol = "TCP"
+	TransportProtocolUDP TransportProtocol = "UDP"
+)
+
+type TransportTLSMode string
+
+const (
+	// TransportTLSPassthrough proxies the TLS stream untouched, the backend terminates it.
+	TransportTLSPassthrough TransportTLSMode = "Passthrough"
+	// TransportTLSTerminate terminates TLS in nginx and proxies plain TCP to the backend.
+	TransportTLSTerminate TransportTLSMode = "Terminate"
+)
+
+// TransportServerReady is the condition reporting whether the TransportServer is rendered into the stream context.
+const TransportServerReady = "Ready"
+
+// TransportServerSpec defines the desired state of TransportServer
+type TransportServerSpec struct {
+	// IngressClassName selects the controller instance serving this TransportServer.
+	// +optional
+	IngressClassName string            `json:"ingressClassName,omitempty"`
+	Listener         TransportListener `json:"listener"`
+	Upstream         TransportUpstream `json:"upstream"`
+	// +optional
+	TLS *TransportTLS `json:"tls,omitempty"`
+}
+
+type TransportListener struct {
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int32 `json:"port"`
+	// +kubebuilder:validation:Enum=TCP;UDP
+	// +kubebuilder:default=TCP
+	// +optional
+	Protocol TransportProtocol `json:"protocol,omitempty"`
+}
+
+type TransportUpstream struct {
+	ServiceName string `json:"serviceName"`
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	ServicePort int32 `json:"servicePort"`
+}
+
+type TransportTLS struct {
+	// +kubebuilder:validation:Enum=Passthrough;Terminate
+	Mode TransportTLSMode `json:"mode"`
+	// Host is matched against the SNI of the client hello, Passthrough servers with different hosts can share a port.
+	// +optional
+	Host string `json:"host,omitempty"`
+	// SecretName is the tls Secret used to terminate TLS, required by the Terminate mode.
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+}
+
+// TransportServerStatus defines the observed state of TransportServer
+type TransportServerStatus struct {
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Port",type=integer,JSONPath=`.spec.listener.port`
+//+kubebuilder:printcolumn:name="Protocol",type=string,JSONPath=`.spec.listener.protocol`
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+
+// TransportServer is the Schema for the transportservers API, it exposes a TCP or UDP Service
+// through the stream context of nginx.
+type TransportServer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   TransportServerSpec   `json:"spec,omitempty"`
+	Status TransportServerStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// TransportServerList contains a list of TransportServer
+type TransportServerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []TransportServer `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&TransportServer{}, &TransportServerList{})
+}
+
+// Protocol returns the listener protocol, TCP when unset.
+func (r *TransportServer) Protocol() TransportProtocol {
+	if r.Spec.Listener.Protocol == "" {
+		return TransportProtocolTCP
+	}
+
+	return r.Spec.Listener.Protocol
+}
+
+// SharesPort reports whether r and o can listen on the same port: only TLS passthrough servers routed by distinct SNI hosts can.
+func (r *TransportServer) SharesPort(o *TransportServer) bool {
+	if r.Spec.TLS == nil || o.Spec.TLS == nil {
+		return false
+	}
+
+	if r.Spec.TLS.Mode != TransportTLSPassthrough || o.Spec.TLS.Mode != TransportTLSPassthrough {
+		return false
+	}
+
+	return r.Spec.TLS.Host != "" && o.Spec.TLS.Host != "" && r.Spec.TLS.Host != o.Spec.TLS.Host
+}
+
+// ConflictsWith reports whether r and o cannot be rendered together by the same controller instance.
+func (r *TransportServer) ConflictsWith(o *TransportServer) bool {
+	if r.Spec.IngressClassName != o.Spec.IngressClassName {
+		return false
+	}
+
+	if r.Spec.Listener.Port != o.Spec.Listener.Port || r.Protocol() != o.Protocol() {
+		return false
+	}
+
+	return !r.SharesPort(o)
+}
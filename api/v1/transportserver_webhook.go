@@ -0,0 +1,141 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// ReservedPorts are the ports of the http context and of the ssl passthrough listeners, a TCP TransportServer can't
+// take them. The manager sets them from the ports of the controller before the webhook is served.
+var ReservedPorts = []int32{80, 443, 442, 441}
+
+var transportserverlog = logf.Log.WithName("transportserver-resource")
+
+// SetupTransportServerWebhookWithManager will setup the manager to manage the webhooks,
+// the validator lists the existing TransportServers to detect port conflicts.
+func SetupTransportServerWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&TransportServer{}).
+		WithValidator(&transportServerValidator{client: mgr.GetClient()}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-ingress-nginx-kubebuilder-io-v1-transportserver,mutating=false,failurePolicy=fail,sideEffects=None,groups=ingress.nginx.kubebuilder.io,resources=transportservers,verbs=create;update,versions=v1,name=vtransportserver.kb.io,admissionReviewVersions=v1
+
+type transportServerValidator struct {
+	client client.Client
+}
+
+var _ webhook.CustomValidator = &transportServerValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *transportServerValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	ts, ok := obj.(*TransportServer)
+	if !ok {
+		return nil, fmt.Errorf("expected a TransportServer but got a %T", obj)
+	}
+	transportserverlog.Info("validate create", "name", ts.Name)
+
+	return nil, v.validate(ctx, ts)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *transportServerValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	ts, ok := newObj.(*TransportServer)
+	if !ok {
+		return nil, fmt.Errorf("expected a TransportServer but got a %T", newObj)
+	}
+	transportserverlog.Info("validate update", "name", ts.Name)
+
+	return nil, v.validate(ctx, ts)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *transportServerValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *transportServerValidator) validate(ctx context.Context, ts *TransportServer) error {
+	if err := ts.ValidSpec(); err != nil {
+		return err
+	}
+
+	var list TransportServerList
+	if err := v.client.List(ctx, &list); err != nil {
+		return fmt.Errorf("unable to list transportservers to check port %d: %v", ts.Spec.Listener.Port, err)
+	}
+
+	for i := range list.Items {
+		o := &list.Items[i]
+		if o.Namespace == ts.Namespace && o.Name == ts.Name {
+			continue
+		}
+
+		if ts.ConflictsWith(o) {
+			return fmt.Errorf("port %d/%s of transportserver: %s, namespace: %s is already used by transportserver: %s, namespace: %s",
+				ts.Spec.Listener.Port, ts.Protocol(), ts.Name, ts.Namespace, o.Name, o.Namespace)
+		}
+	}
+
+	return nil
+}
+
+func (r *TransportServer) ValidSpec() error {
+	if r.Protocol() == TransportProtocolTCP {
+		for _, p := range ReservedPorts {
+			if r.Spec.Listener.Port == p {
+				return fmt.Errorf("port %d of transportserver: %s, namespace: %s is reserved by the controller", p, r.Name, r.Namespace)
+			}
+		}
+	}
+
+	if r.Spec.TLS == nil {
+		return nil
+	}
+
+	if r.Protocol() == TransportProtocolUDP {
+		return fmt.Errorf("tls is not supported for udp in transportserver: %s, namespace: %s", r.Name, r.Namespace)
+	}
+
+	switch r.Spec.TLS.Mode {
+	case TransportTLSTerminate:
+		if r.Spec.TLS.SecretName == "" {
+			return fmt.Errorf("tls mode %s requires a secretName in transportserver: %s, namespace: %s", r.Spec.TLS.Mode, r.Name, r.Namespace)
+		}
+	case TransportTLSPassthrough:
+		if r.Spec.TLS.SecretName != "" {
+			return fmt.Errorf("tls mode %s does not use a secretName in transportserver: %s, namespace: %s", r.Spec.TLS.Mode, r.Name, r.Namespace)
+		}
+		if r.Spec.TLS.Host != "" {
+			if !IsValidHost(r.Spec.TLS.Host) {
+				return fmt.Errorf("host: %s is an invalid value in transportserver: %s, namespace: %s", r.Spec.TLS.Host, r.Name, r.Namespace)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown tls mode %s in transportserver: %s, namespace: %s", r.Spec.TLS.Mode, r.Name, r.Namespace)
+	}
+
+	return nil
+}
@@ -19,22 +19,33 @@ package main
 import (
 	"crypto/tls"
 	"flag"
+	"fmt"
 	"os"
+	"strings"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	netv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	ingressv1 "github.com/ingoxx/ingress-nginx-kubebuilder/api/v1"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/customerrors"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/sslprofile"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/upstream"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/config"
 	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/controller"
 	//+kubebuilder:scaffold:imports
 )
@@ -57,6 +68,16 @@ func main() {
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var leaderElectionID string
+	var ingressClass string
+	var controllerClass string
+	var watchNamespaces string
+	var watchSelector string
+	var enableCoreIngress bool
+	var publishService string
+	var publishStatusAddress string
+	var enableGatewayAPI bool
+	var enableTransportServer bool
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
@@ -66,6 +87,107 @@ func main() {
 		"If set the metrics endpoint is served securely")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.StringVar(&leaderElectionID, "leader-election-id", "6d7d1050.nginx.kubebuilder.io",
+		"The leader election lease name, must be unique per controller instance sharing a cluster.")
+	flag.StringVar(&ingressClass, "ingress-class", controller.DefaultIngressClass,
+		"Name of the IngressClass (or value of the kubernetes.io/ingress.class annotation) served by this controller.")
+	flag.StringVar(&controllerClass, "controller-class", controller.DefaultControllerClass,
+		"The spec.controller value an IngressClass must carry to be served by this controller.")
+	flag.StringVar(&watchNamespaces, "watch-namespace", "",
+		"Comma separated list of namespaces to watch, all namespaces are watched if empty.")
+	flag.StringVar(&watchSelector, "watch-selector", "",
+		"Label selector an Ingress must match to be watched, e.g: fleet=internal.")
+	flag.BoolVar(&enableCoreIngress, "enable-core-ingress", true,
+		"If set, networking.k8s.io/v1 Ingresses of the served class are reconciled alongside the custom resource.")
+	flag.StringVar(&publishService, "publish-service", "",
+		"namespace/name of the Service whose load balancer address is written to the status of core Ingresses.")
+	flag.StringVar(&publishStatusAddress, "publish-status-address", "",
+		"Comma separated addresses written to the status of core Ingresses when publish-service is not set.")
+	flag.BoolVar(&enableGatewayAPI, "enable-gateway-api", false,
+		"If set, Gateways and HTTPRoutes of a GatewayClass whose controllerName is controller-class are reconciled, "+
+			"the gateway.networking.k8s.io CRDs must be installed.")
+	flag.BoolVar(&enableTransportServer, "enable-transport-server", true,
+		"If set, TransportServers of the served class are rendered into the stream context.")
+	flag.BoolVar(&config.SSLPassthrough, "enable-ssl-passthrough", false,
+		"If set, a stream listener owns the https port and routes the hosts of Ingresses annotated with ssl-passthrough "+
+			"by SNI to their backend, the https servers then listen on the loopback.")
+	flag.StringVar(&config.DefaultCertClusterIssuer, "default-cert-cluster-issuer", "",
+		"cert-manager ClusterIssuer signing the certificates of Ingresses without spec.tls that select no issuer.")
+	flag.StringVar(&config.DefaultCertIssuer, "default-cert-issuer", "",
+		"cert-manager Issuer, looked up in the namespace of the Ingress, used like default-cert-cluster-issuer.")
+	flag.StringVar(&config.ACMEEmail, "acme-email", "",
+		"If set and no issuer is selected, each Ingress gets an ACME Issuer registered with this email solving http01 "+
+			"challenges through the controller, instead of a self signed Issuer.")
+	flag.StringVar(&config.ACMEServer, "acme-server", config.ACMEServer, "Directory URL of the ACME server.")
+	flag.StringVar(&config.DefaultSSLCertificate, "default-ssl-certificate", "",
+		"namespace/name of the tls Secret served to the hosts without a certificate of their own, "+
+			"a self signed certificate is served when it is not set.")
+	flag.IntVar(&config.SSLExpiryWarningDays, "ssl-expiry-warning-days", config.SSLExpiryWarningDays,
+		"Warning events are recorded on an Ingress when a certificate it serves expires within this many days.")
+	flag.StringVar(&config.SSLProfile, "ssl-profile", config.SSLProfile,
+		"tls profile of the https servers without an ssl-profile annotation: modern, intermediate, legacy or custom.")
+	flag.StringVar(&config.SSLProtocols, "ssl-protocols", "",
+		"ssl_protocols overriding the ssl-profile, e.g. \"TLSv1.2 TLSv1.3\", required by the custom profile.")
+	flag.StringVar(&config.SSLCiphers, "ssl-ciphers", "",
+		"OpenSSL cipher list overriding the ssl-profile, required by the custom profile.")
+	flag.IntVar(&config.HSTSMaxAge, "hsts-max-age", config.HSTSMaxAge,
+		"Default max-age of the Strict-Transport-Security header, 0 disables the header.")
+	flag.BoolVar(&config.HSTSIncludeSubdomains, "hsts-include-subdomains", false,
+		"If set, the Strict-Transport-Security header includes subdomains by default.")
+	flag.BoolVar(&config.HSTSPreload, "hsts-preload", false,
+		"If set, the Strict-Transport-Security header carries preload by default.")
+	flag.BoolVar(&config.SSLRedirect, "ssl-redirect", false,
+		"If set, plain http requests to hosts with a certificate are redirected to https unless the Ingress sets ssl-redirect.")
+	flag.StringVar(&config.CustomHTTPErrors, "custom-http-errors", "",
+		"Comma separated upstream status codes sent to the default-error-backend unless the Ingress sets custom-http-errors.")
+	flag.StringVar(&config.DefaultErrorBackend, "default-error-backend", "",
+		"namespace/name:port of the error page Service used by the custom http errors.")
+	flag.IntVar(&config.UpstreamKeepalive, "upstream-keepalive", config.UpstreamKeepalive,
+		"Idle connections to the backends kept by each nginx worker, 0 disables keepalive.")
+	flag.IntVar(&config.UpstreamKeepaliveRequests, "upstream-keepalive-requests", config.UpstreamKeepaliveRequests,
+		"Requests sent over a kept backend connection before it is closed.")
+	flag.StringVar(&config.UpstreamKeepaliveTimeout, "upstream-keepalive-timeout", config.UpstreamKeepaliveTimeout,
+		"How long an idle backend connection is kept.")
+	flag.IntVar(&config.UpstreamMaxFails, "upstream-max-fails", config.UpstreamMaxFails,
+		"Failed attempts within upstream-fail-timeout taking a backend out, 0 never does.")
+	flag.StringVar(&config.UpstreamFailTimeout, "upstream-fail-timeout", config.UpstreamFailTimeout,
+		"Window of upstream-max-fails and how long a failing backend is taken out.")
+	flag.BoolVar(&config.HealthChecks, "enable-health-checks", false,
+		"If set, the endpoints of the backends of an Ingress with health-check-path are probed and the failing ones taken out.")
+	flag.StringVar(&config.ProxyRealIPCIDR, "proxy-real-ip-cidr", "",
+		"Comma separated addresses and CIDRs of the trusted proxies the client address is taken from, every address when empty.")
+	flag.BoolVar(&config.UseForwardedHeaders, "use-forwarded-headers", false,
+		"Take the client address from the X-Forwarded-For header of the trusted proxies, needs proxy-real-ip-cidr.")
+	flag.BoolVar(&config.UseProxyProtocol, "use-proxy-protocol", false,
+		"Expect the PROXY protocol on the http and https listeners and take the client address from it.")
+	flag.StringVar(&config.GeoIP2CountryDB, "geoip2-country-db", "",
+		"Path of the MaxMind format country database of the geo-allow-countries and geo-deny-countries annotations.")
+	flag.StringVar(&config.GeoIP2Module, "geoip2-module", "",
+		"Path of the ngx_http_geoip2_module to load, empty when nginx is built with it.")
+	flag.StringVar(&config.ModSecurityModule, "modsecurity-module", "",
+		"Path of the ngx_http_modsecurity_module to load, empty when nginx is built with it.")
+	flag.StringVar(&config.ModSecurityRulesFile, "modsecurity-rules-file", config.ModSecurityRulesFile,
+		"ModSecurity configuration loaded by the locations of an Ingress with enable-modsecurity.")
+	flag.StringVar(&config.OWASPCoreRulesFile, "owasp-core-rules-file", config.OWASPCoreRulesFile,
+		"OWASP core rule set loaded by the locations of an Ingress with enable-owasp-core-rules.")
+	flag.BoolVar(&config.AllowSnippetAnnotations, "allow-snippet-annotations", false,
+		"If set, the server-snippet and configuration-snippet annotations are rendered, the Ingresses using them are refused otherwise.")
+	flag.StringVar(&config.SnippetNamespaces, "snippet-namespaces", "",
+		"Comma separated namespaces whose Ingresses can use the snippet annotations, every namespace when empty.")
+	flag.StringVar(&config.SnippetAllowedDirectives, "snippet-allowed-directives", "",
+		"Comma separated shell patterns of the directives a snippet can use, every directive not denied when empty.")
+	flag.StringVar(&config.SnippetDeniedDirectives, "snippet-denied-directives", "",
+		"Comma separated shell patterns of the directives a snippet can't use, on top of include, root, alias, load_module and lua, perl and js.")
+	flag.StringVar(&config.ConfDir, "nginx-conf-dir", config.ConfDir, "Directory the generated server configuration is written to.")
+	flag.StringVar(&config.StreamConfDir, "nginx-stream-conf-dir", config.StreamConfDir,
+		"Directory the generated stream configuration is written to.")
+	flag.StringVar(&config.AcmeConfDir, "nginx-acme-conf-dir", config.AcmeConfDir,
+		"Directory the acme http01 challenge locations are written to.")
+	flag.StringVar(&config.SslPath, "nginx-ssl-dir", config.SslPath, "Directory the certificates are written to.")
+	flag.StringVar(&config.MainConf, "nginx-main-conf", config.MainConf, "Path of the main nginx configuration file.")
+	flag.StringVar(&config.Pid, "nginx-pid", config.Pid, "Path of the nginx pid file.")
+	flag.StringVar(&config.Bin, "nginx-bin", config.Bin, "Path of the nginx binary.")
+	templateDir := flag.String("nginx-template-dir", config.TemplateDir, "Directory containing the nginx templates.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -74,6 +196,38 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	config.SetTemplateDir(*templateDir)
+
+	sslprofile.Load(config.Bin)
+
+	if err := sslprofile.ValidateDefaults(); err != nil {
+		setupLog.Error(err, "invalid tls settings")
+		os.Exit(1)
+	}
+
+	if err := customerrors.ValidateDefaults(); err != nil {
+		setupLog.Error(err, "invalid custom http errors settings")
+		os.Exit(1)
+	}
+
+	if err := upstream.ValidateDefaults(); err != nil {
+		setupLog.Error(err, "invalid upstream settings")
+		os.Exit(1)
+	}
+
+	if err := config.ValidateRealIP(); err != nil {
+		setupLog.Error(err, "invalid real ip settings")
+		os.Exit(1)
+	}
+
+	ingressv1.ReservedPorts = config.ReservedPorts()
+
+	cacheOpts, err := newCacheOptions(watchNamespaces, watchSelector)
+	if err != nil {
+		setupLog.Error(err, "invalid watch options")
+		os.Exit(1)
+	}
+
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
 	// due to its vulnerabilities. More specifically, disabling http/2 will
 	// prevent from being vulnerable to the HTTP/2 Stream Cancellation and
@@ -101,10 +255,11 @@ func main() {
 			SecureServing: secureMetrics,
 			TLSOpts:       tlsOpts,
 		},
+		Cache:                  cacheOpts,
 		WebhookServer:          webhookServer,
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
-		LeaderElectionID:       "6d7d1050.nginx.kubebuilder.io",
+		LeaderElectionID:       leaderElectionID,
 		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
 		// when the Manager ends. This requires the binary to immediately end when the
 		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
@@ -123,18 +278,95 @@ func main() {
 	}
 
 	if err = (&controller.IngressReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:          mgr.GetClient(),
+		Scheme:          mgr.GetScheme(),
+		IngressClass:    ingressClass,
+		ControllerClass: controllerClass,
+		Recorder:        mgr.GetEventRecorderFor("ingress-nginx-controller"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Ingress")
 		os.Exit(1)
 	}
 
+	if enableCoreIngress {
+		if err = (&controller.CoreIngressReconciler{
+			IngressReconciler: controller.IngressReconciler{
+				Client:          mgr.GetClient(),
+				Scheme:          mgr.GetScheme(),
+				IngressClass:    ingressClass,
+				ControllerClass: controllerClass,
+				Recorder:        mgr.GetEventRecorderFor("ingress-nginx-controller"),
+			},
+			PublishService:       publishService,
+			PublishStatusAddress: publishStatusAddress,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "CoreIngress")
+			os.Exit(1)
+		}
+	}
+
+	if enableGatewayAPI {
+		if err = (&controller.GatewayReconciler{
+			Client:               mgr.GetClient(),
+			Scheme:               mgr.GetScheme(),
+			ControllerName:       controllerClass,
+			PublishService:       publishService,
+			PublishStatusAddress: publishStatusAddress,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "Gateway")
+			os.Exit(1)
+		}
+		if err = (&controller.GatewayClassReconciler{
+			Client:         mgr.GetClient(),
+			Scheme:         mgr.GetScheme(),
+			ControllerName: controllerClass,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "GatewayClass")
+			os.Exit(1)
+		}
+	}
+
+	if config.DefaultSSLCertificate != "" {
+		ns, name, found := strings.Cut(config.DefaultSSLCertificate, "/")
+		if !found || ns == "" || name == "" {
+			setupLog.Error(fmt.Errorf("%s is not in namespace/name format", config.DefaultSSLCertificate), "invalid default-ssl-certificate")
+			os.Exit(1)
+		}
+
+		if err = (&controller.DefaultCertificateReconciler{
+			Client: mgr.GetClient(),
+			Scheme: mgr.GetScheme(),
+			Secret: types.NamespacedName{Namespace: ns, Name: name},
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "DefaultCertificate")
+			os.Exit(1)
+		}
+	}
+
+	if enableTransportServer {
+		if err = (&controller.TransportServerReconciler{
+			IngressReconciler: controller.IngressReconciler{
+				Client:          mgr.GetClient(),
+				Scheme:          mgr.GetScheme(),
+				IngressClass:    ingressClass,
+				ControllerClass: controllerClass,
+			},
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "TransportServer")
+			os.Exit(1)
+		}
+	}
+
 	if err = (&ingressv1.Ingress{}).SetupWebhookWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create webhook", "webhook", "Ingress")
 		os.Exit(1)
 	}
 
+	if err = ingressv1.SetupTransportServerWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "TransportServer")
+		os.Exit(1)
+	}
+
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -152,3 +384,32 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// newCacheOptions restricts the manager cache to the watched namespaces, and the Ingress informers to the given selector.
+func newCacheOptions(namespaces, selector string) (cache.Options, error) {
+	opts := cache.Options{}
+
+	for _, ns := range strings.Split(namespaces, ",") {
+		ns = strings.TrimSpace(ns)
+		if ns == "" {
+			continue
+		}
+		if opts.DefaultNamespaces == nil {
+			opts.DefaultNamespaces = make(map[string]cache.Config)
+		}
+		opts.DefaultNamespaces[ns] = cache.Config{}
+	}
+
+	if selector != "" {
+		sel, err := labels.Parse(selector)
+		if err != nil {
+			return opts, err
+		}
+		opts.ByObject = map[client.Object]cache.ByObject{
+			&ingressv1.Ingress{}: {Label: sel},
+			&netv1.Ingress{}:     {Label: sel},
+		}
+	}
+
+	return opts, nil
+}
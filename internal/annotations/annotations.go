@@ -5,14 +5,26 @@ import (
 	"github.com/imdario/mergo"
 	ingressv1 "github.com/ingoxx/ingress-nginx-kubebuilder/api/v1"
 	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/allowcos"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/certmanager"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/customerrors"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/geoip"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/headers"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/healthcheck"
 	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/ipallowlist"
 	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/ipdenylist"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/mirror"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/modsecurity"
 	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/parser"
 	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/proxy"
 	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/redirect"
 	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/resolver"
 	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/rewrite"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/snippet"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/sslpassthrough"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/sslprofile"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/sslredirect"
 	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/sslstapling"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/upstream"
 	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/weight"
 	kerr "github.com/ingoxx/ingress-nginx-kubebuilder/internal/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -21,14 +33,26 @@ import (
 
 type Ingress struct {
 	metav1.ObjectMeta
-	Proxy       proxy.Config
-	Rewrite     rewrite.Config
-	Redirect    redirect.Config
-	SSLStapling sslstapling.Config
-	AllowList   ipallowlist.SourceRange
-	DenyList    ipdenylist.SourceRange
-	AllowCos    allowcos.Config
-	Weight      weight.BackendWeight
+	Proxy        proxy.Config
+	Rewrite      rewrite.Config
+	Redirect     redirect.Config
+	SSLStapling  sslstapling.Config
+	SSLProfile   sslprofile.Config
+	SSLRedirect  sslredirect.Config
+	Passthrough  sslpassthrough.Config
+	CertManager  certmanager.Config
+	AllowList    ipallowlist.SourceRange
+	DenyList     ipdenylist.SourceRange
+	AllowCos     allowcos.Config
+	Headers      headers.Config
+	CustomErrors customerrors.Config
+	Mirror       mirror.Config
+	Upstream     upstream.Config
+	HealthCheck  healthcheck.Config
+	GeoIP        geoip.Config
+	ModSecurity  modsecurity.Config
+	Snippet      snippet.Config
+	Weight       weight.BackendWeight
 }
 
 func (i *Ingress) GetIngressAnnotations() {}
@@ -42,19 +66,33 @@ type Extractor struct {
 
 type IngressAnnotations struct {
 	ParsedAnnotations *Ingress `json:"parsed_annotations"`
+	// PathAnnotations are the annotations of the paths of the path overrides of the ingress
+	PathAnnotations []*PathAnnotations `json:"path_annotations,omitempty"`
 }
 
 func NewAnnotationExtractor(r resolver.Resolver) *Extractor {
 	return &Extractor{
 		map[string]parser.IngressAnnotation{
-			"Proxy":       proxy.NewParser(r),
-			"Redirect":    redirect.NewParser(r),
-			"AllowList":   ipallowlist.NewParser(r),
-			"DenyList":    ipdenylist.NewParser(r),
-			"Rewrite":     rewrite.NewParser(r),
-			"SSLStapling": sslstapling.NewParser(r),
-			"AllowCos":    allowcos.NewParser(r),
-			"Weight":      weight.NewParser(r),
+			"Proxy":        proxy.NewParser(r),
+			"Redirect":     redirect.NewParser(r),
+			"AllowList":    ipallowlist.NewParser(r),
+			"DenyList":     ipdenylist.NewParser(r),
+			"Rewrite":      rewrite.NewParser(r),
+			"SSLStapling":  sslstapling.NewParser(r),
+			"SSLProfile":   sslprofile.NewParser(r),
+			"SSLRedirect":  sslredirect.NewParser(r),
+			"Passthrough":  sslpassthrough.NewParser(r),
+			"CertManager":  certmanager.NewParser(r),
+			"AllowCos":     allowcos.NewParser(r),
+			"Headers":      headers.NewParser(r),
+			"CustomErrors": customerrors.NewParser(r),
+			"Mirror":       mirror.NewParser(r),
+			"Upstream":     upstream.NewParser(r),
+			"HealthCheck":  healthcheck.NewParser(r),
+			"GeoIP":        geoip.NewParser(r),
+			"ModSecurity":  modsecurity.NewParser(r),
+			"Snippet":      snippet.NewParser(r),
+			"Weight":       weight.NewParser(r),
 		},
 	}
 }
@@ -98,6 +136,11 @@ func (e Extractor) Extract(ing *ingressv1.Ingress) (*Ingress, error) {
 				return nil, err
 			}
 
+			if kerr.IsRiskyAnnotationError(err) {
+				klog.ErrorS(err, "ingress contains a risky annotation refused by the policy")
+				return nil, err
+			}
+
 			if kerr.IsMissAnnotationsError(err) {
 				klog.ErrorS(err, "")
 				return nil, err
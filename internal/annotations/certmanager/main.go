@@ -0,0 +1,132 @@
+package certmanager
+
+import (
+	ingressv1 "github.com/ingoxx/ingress-nginx-kubebuilder/api/v1"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/parser"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/resolver"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/errors"
+	"k8s.io/klog/v2"
+	"time"
+)
+
+const (
+	certIssuer        = "cert-issuer"
+	certClusterIssuer = "cert-cluster-issuer"
+	certDuration      = "cert-duration"
+	certRenewBefore   = "cert-renew-before"
+	certDisable       = "cert-disable"
+)
+
+var certAnnotations = parser.Annotation{
+	Group: "certManager",
+	Annotations: parser.AnnotationFields{
+		certIssuer: {
+			Doc: "name of the cert-manager Issuer in the ingress namespace signing the certificate, optional",
+		},
+		certClusterIssuer: {
+			Doc: "name of the cert-manager ClusterIssuer signing the certificate, e.g: `letsencrypt`, optional",
+		},
+		certDuration: {
+			Doc: "requested lifetime of the certificate, e.g: `2160h`, optional",
+		},
+		certRenewBefore: {
+			Doc: "how long before expiry the certificate is renewed, e.g: `360h`, optional",
+		},
+		certDisable: {
+			Doc: "don't request a certificate from cert-manager for an ingress without spec.tls, optional",
+		},
+	},
+}
+
+type Config struct {
+	Issuer        string `json:"cert-issuer"`
+	ClusterIssuer string `json:"cert-cluster-issuer"`
+	Duration      string `json:"cert-duration"`
+	RenewBefore   string `json:"cert-renew-before"`
+	Disable       bool   `json:"cert-disable"`
+}
+
+type certManager struct {
+	r resolver.Resolver
+}
+
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return &certManager{}
+}
+
+func (c *certManager) Parse(ing *ingressv1.Ingress) (interface{}, error) {
+	var err error
+	config := &Config{}
+
+	config.Issuer, err = parser.GetStringAnnotation(certIssuer, ing, certAnnotations.Annotations)
+	if err != nil {
+		if errors.IsValidationError(err) {
+			klog.Warningf("%s is invalid, defaulting to empty", certIssuer)
+		}
+	}
+
+	config.ClusterIssuer, err = parser.GetStringAnnotation(certClusterIssuer, ing, certAnnotations.Annotations)
+	if err != nil {
+		if errors.IsValidationError(err) {
+			klog.Warningf("%s is invalid, defaulting to empty", certClusterIssuer)
+		}
+	}
+
+	config.Duration, err = parser.GetStringAnnotation(certDuration, ing, certAnnotations.Annotations)
+	if err != nil {
+		if errors.IsValidationError(err) {
+			klog.Warningf("%s is invalid, defaulting to empty", certDuration)
+		}
+	}
+
+	config.RenewBefore, err = parser.GetStringAnnotation(certRenewBefore, ing, certAnnotations.Annotations)
+	if err != nil {
+		if errors.IsValidationError(err) {
+			klog.Warningf("%s is invalid, defaulting to empty", certRenewBefore)
+		}
+	}
+
+	config.Disable, err = parser.GetBoolAnnotations(certDisable, ing, certAnnotations.Annotations)
+	if err != nil {
+		if errors.IsValidationError(err) {
+			klog.Warningf("%s is invalid, defaulting to false", certDisable)
+		}
+		config.Disable = false
+	}
+
+	if err := c.check(config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+func (c *certManager) Validate(anns map[string]string) error {
+	return parser.CheckAnnotations(anns, certAnnotations.Annotations)
+}
+
+func (c *certManager) check(cfg *Config) error {
+	if cfg.Issuer != "" && cfg.ClusterIssuer != "" {
+		return errors.NewInvalidAnnotationsContentError(certClusterIssuer, cfg.ClusterIssuer+", "+certIssuer+" is already set")
+	}
+
+	var duration, renewBefore time.Duration
+	var err error
+	if cfg.Duration != "" {
+		if duration, err = time.ParseDuration(cfg.Duration); err != nil || duration <= 0 {
+			return errors.NewInvalidAnnotationsContentError(certDuration, cfg.Duration)
+		}
+	}
+
+	if cfg.RenewBefore != "" {
+		if renewBefore, err = time.ParseDuration(cfg.RenewBefore); err != nil || renewBefore <= 0 {
+			return errors.NewInvalidAnnotationsContentError(certRenewBefore, cfg.RenewBefore)
+		}
+	}
+
+	if duration > 0 && renewBefore >= duration {
+		return errors.NewInvalidAnnotationsContentError(certRenewBefore, cfg.RenewBefore+" is not shorter than "+cfg.Duration)
+	}
+
+	return nil
+}
@@ -0,0 +1,179 @@
+package customerrors
+
+import (
+	"fmt"
+	ingressv1 "github.com/ingoxx/ingress-nginx-kubebuilder/api/v1"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/parser"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/resolver"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/config"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/klog/v2"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	customHTTPErrors = "custom-http-errors"
+	defaultBackend   = "default-backend"
+)
+
+var customErrorsAnnotations = parser.Annotation{
+	Group: "customErrors",
+	Annotations: parser.AnnotationFields{
+		customHTTPErrors: {
+			Doc: "comma separated upstream status codes served by the error page Service, e.g: `404,503`, optional",
+		},
+		defaultBackend: {
+			Doc: "error page Service of the ingress namespace, `name` or `name:port`, defaults to the global error backend, optional",
+		},
+	},
+}
+
+// Config sends the responses of the backends with one of Codes to the error page Service at Backend, which gets
+// the X-Code, X-Format, X-Original-URI, X-Namespace, X-Ingress-Name, X-Service-Name and X-Service-Port headers.
+type Config struct {
+	Codes   []int  `json:"codes"`
+	Backend string `json:"backend"`
+}
+
+type customErrors struct {
+	r resolver.Resolver
+}
+
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return &customErrors{r: r}
+}
+
+func (c *customErrors) Parse(ing *ingressv1.Ingress) (interface{}, error) {
+	cfg := &Config{}
+
+	codes, err := parser.GetStringAnnotation(customHTTPErrors, ing, customErrorsAnnotations.Annotations)
+	if err != nil {
+		if errors.IsValidationError(err) {
+			klog.Warningf("%s is invalid, defaulting to %s", customHTTPErrors, config.CustomHTTPErrors)
+		}
+	}
+	if codes == "" {
+		codes = config.CustomHTTPErrors
+	}
+	if cfg.Codes, err = ParseCodes(codes); err != nil {
+		return nil, errors.NewInvalidAnnotationsContentError(customHTTPErrors, fmt.Sprintf("%s, %v", codes, err))
+	}
+
+	if len(cfg.Codes) == 0 {
+		return cfg, nil
+	}
+
+	backend, err := parser.GetStringAnnotation(defaultBackend, ing, customErrorsAnnotations.Annotations)
+	if err != nil {
+		if errors.IsValidationError(err) {
+			klog.Warningf("%s is invalid, defaulting to %s", defaultBackend, config.DefaultErrorBackend)
+		}
+	}
+
+	switch {
+	case backend != "":
+		if cfg.Backend, err = c.serviceAddress(backend, ing.Namespace); err != nil {
+			return nil, err
+		}
+	case config.DefaultErrorBackend != "":
+		if cfg.Backend, err = globalBackend(config.DefaultErrorBackend); err != nil {
+			return nil, errors.NewNotSatisfiableError(err.Error())
+		}
+	default:
+		return nil, errors.NewInvalidAnnotationsContentError(customHTTPErrors, codes+", no "+defaultBackend+" is set")
+	}
+
+	return cfg, nil
+}
+
+func (c *customErrors) Validate(anns map[string]string) error {
+	return parser.CheckAnnotations(anns, customErrorsAnnotations.Annotations)
+}
+
+// serviceAddress resolves name or name:port of the ingress namespace to the address of the Service, the first port
+// of the Service is used when none is given.
+func (c *customErrors) serviceAddress(backend, namespace string) (string, error) {
+	if c.r == nil {
+		return "", errors.NewNotSatisfiableError(fmt.Sprintf("service: %s of %s can't be read", backend, defaultBackend))
+	}
+
+	name, port, _ := strings.Cut(backend, ":")
+	if len(validation.IsDNS1035Label(name)) > 0 {
+		return "", errors.NewInvalidAnnotationsContentError(defaultBackend, backend)
+	}
+
+	svc, err := c.r.GetService(name)
+	if err != nil {
+		return "", errors.NewNotSatisfiableError(err.Error())
+	}
+
+	for _, p := range svc.Spec.Ports {
+		if port == "" || port == p.Name || port == strconv.Itoa(int(p.Port)) {
+			return fmt.Sprintf("%s.%s.svc:%d", name, namespace, p.Port), nil
+		}
+	}
+
+	return "", errors.NewNotSatisfiableError(fmt.Sprintf("port: %s of %s not exists in service: %s, namespace: %s", port, defaultBackend, name, namespace))
+}
+
+// ParseCodes reads a comma separated list of the status codes nginx can intercept, sorted and without duplicates.
+func ParseCodes(list string) ([]int, error) {
+	seen := make(map[int]bool)
+	var codes []int
+	for _, v := range strings.Split(list, ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+
+		code, err := strconv.Atoi(v)
+		if err != nil || code < 400 || code > 599 {
+			return nil, fmt.Errorf("invalid status code %s", v)
+		}
+
+		if !seen[code] {
+			seen[code] = true
+			codes = append(codes, code)
+		}
+	}
+
+	sort.Ints(codes)
+
+	return codes, nil
+}
+
+// globalBackend turns the namespace/name:port of the global error backend into its address.
+func globalBackend(backend string) (string, error) {
+	ref, port, ok := strings.Cut(backend, ":")
+	namespace, name, found := strings.Cut(ref, "/")
+	if !ok || !found || len(validation.IsDNS1123Label(namespace)) > 0 || len(validation.IsDNS1035Label(name)) > 0 {
+		return "", fmt.Errorf("error backend %s is not namespace/name:port", backend)
+	}
+
+	if n, err := strconv.Atoi(port); err != nil || len(validation.IsValidPortNum(n)) > 0 {
+		return "", fmt.Errorf("invalid port of error backend %s", backend)
+	}
+
+	return fmt.Sprintf("%s.%s.svc:%s", name, namespace, port), nil
+}
+
+// ValidateDefaults checks the global custom error settings given on the command line.
+func ValidateDefaults() error {
+	codes, err := ParseCodes(config.CustomHTTPErrors)
+	if err != nil {
+		return err
+	}
+
+	if config.DefaultErrorBackend == "" {
+		if len(codes) > 0 {
+			return fmt.Errorf("custom http errors %s need an error backend", config.CustomHTTPErrors)
+		}
+		return nil
+	}
+
+	_, err = globalBackend(config.DefaultErrorBackend)
+
+	return err
+}
@@ -0,0 +1,117 @@
+package geoip
+
+import (
+	"fmt"
+	ingressv1 "github.com/ingoxx/ingress-nginx-kubebuilder/api/v1"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/parser"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/resolver"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/config"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	"slices"
+	"strings"
+)
+
+const (
+	geoAllowCountries = "geo-allow-countries"
+	geoDenyCountries  = "geo-deny-countries"
+)
+
+var geoipAnnotations = parser.Annotation{
+	Group: "geoip",
+	Annotations: parser.AnnotationFields{
+		geoAllowCountries: {
+			Doc: "comma separated ISO 3166-1 alpha-2 country codes allowed to access the ingress, the others are refused, e.g: `US,CA`, optional",
+		},
+		geoDenyCountries: {
+			Doc: "comma separated ISO 3166-1 alpha-2 country codes refused access to the ingress, e.g: `KP,IR`, optional",
+		},
+	},
+}
+
+// countries are the ISO 3166-1 alpha-2 codes, with the XK code MaxMind uses for Kosovo.
+var countries = sets.NewString(strings.Fields(`
+	AD AE AF AG AI AL AM AO AQ AR AS AT AU AW AX AZ BA BB BD BE BF BG BH BI BJ BL BM BN BO BQ BR BS BT BV BW BY BZ
+	CA CC CD CF CG CH CI CK CL CM CN CO CR CU CV CW CX CY CZ DE DJ DK DM DO DZ EC EE EG EH ER ES ET FI FJ FK FM FO
+	FR GA GB GD GE GF GG GH GI GL GM GN GP GQ GR GS GT GU GW GY HK HM HN HR HT HU ID IE IL IM IN IO IQ IR IS IT JE
+	JM JO JP KE KG KH KI KM KN KP KR KW KY KZ LA LB LC LI LK LR LS LT LU LV LY MA MC MD ME MF MG MH MK ML MM MN MO
+	MP MQ MR MS MT MU MV MW MX MY MZ NA NC NE NF NG NI NL NO NP NR NU NZ OM PA PE PF PG PH PK PL PM PN PR PS PT PW
+	PY QA RE RO RS RU RW SA SB SC SD SE SG SH SI SJ SK SL SM SN SO SR SS ST SV SX SY SZ TC TD TF TG TH TJ TK TL TM
+	TN TO TR TT TV TW TZ UA UG UM US UY UZ VA VC VE VG VI VN VU WF WS XK YE YT ZA ZM ZW`)...)
+
+// Config refuses the clients of DenyCountries and, when AllowCountries is set, of every other country. The country
+// is looked up in the GeoIP2 database from the client address.
+type Config struct {
+	AllowCountries []string `json:"allow-countries,omitempty"`
+	DenyCountries  []string `json:"deny-countries,omitempty"`
+}
+
+// Enabled reports whether the clients are filtered by country.
+func (c Config) Enabled() bool {
+	return len(c.AllowCountries) > 0 || len(c.DenyCountries) > 0
+}
+
+type geoip struct {
+	r resolver.Resolver
+}
+
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return &geoip{r: r}
+}
+
+func (g *geoip) Parse(ing *ingressv1.Ingress) (interface{}, error) {
+	cfg := &Config{}
+
+	var err error
+	if cfg.AllowCountries, err = parseCountries(geoAllowCountries, ing); err != nil {
+		return nil, err
+	}
+	if cfg.DenyCountries, err = parseCountries(geoDenyCountries, ing); err != nil {
+		return nil, err
+	}
+
+	for _, code := range cfg.AllowCountries {
+		if slices.Contains(cfg.DenyCountries, code) {
+			return nil, errors.NewInvalidAnnotationsContentError(geoDenyCountries, fmt.Sprintf("%s is also allowed by %s", code, geoAllowCountries))
+		}
+	}
+
+	if cfg.Enabled() && config.GeoIP2Database() == "" {
+		err = errors.NewNotSatisfiableError(fmt.Sprintf("%s and %s need the GeoIP2 country database, set with --geoip2-country-db",
+			geoAllowCountries, geoDenyCountries))
+		klog.ErrorS(err, fmt.Sprintf("the country database %q is missing", config.GeoIP2CountryDB))
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func (g *geoip) Validate(anns map[string]string) error {
+	return parser.CheckAnnotations(anns, geoipAnnotations.Annotations)
+}
+
+func parseCountries(name string, ing *ingressv1.Ingress) ([]string, error) {
+	val, err := parser.GetStringAnnotation(name, ing, geoipAnnotations.Annotations)
+	if err != nil {
+		if errors.IsValidationError(err) {
+			klog.Warningf("%s is invalid, defaulting to empty slice", name)
+		}
+	}
+
+	codes := sets.NewString()
+	for _, code := range strings.Split(val, ",") {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if code == "" {
+			continue
+		}
+
+		if !countries.Has(code) {
+			return nil, errors.NewInvalidAnnotationsContentError(name, code)
+		}
+
+		codes.Insert(code)
+	}
+
+	return codes.List(), nil
+}
@@ -0,0 +1,223 @@
+package headers
+
+import (
+	"fmt"
+	ingressv1 "github.com/ingoxx/ingress-nginx-kubebuilder/api/v1"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/parser"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/resolver"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/klog/v2"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	proxySetHeaders     = "proxy-set-headers"
+	addResponseHeaders  = "add-response-headers"
+	hideUpstreamHeaders = "hide-upstream-headers"
+	upstreamVhost       = "upstream-vhost"
+)
+
+var headerAnnotations = parser.Annotation{
+	Group: "headers",
+	Annotations: parser.AnnotationFields{
+		proxySetHeaders: {
+			Doc: "name of a ConfigMap in the ingress namespace whose data are the headers sent to the backends, header: value, optional",
+		},
+		addResponseHeaders: {
+			Doc: "headers added to every response, one `Header: value` per line, optional",
+		},
+		hideUpstreamHeaders: {
+			Doc: "comma separated headers of the backend responses not passed to the clients, e.g: `X-Powered-By,Server`, optional",
+		},
+		upstreamVhost: {
+			Doc: "Host header sent to the backends, e.g: `internal.example.com`, optional",
+		},
+	},
+}
+
+// reservedHeaders are set by the controller on every proxied request, they can't be overridden with proxy-set-headers.
+var reservedHeaders = []string{
+	"Host", "Upgrade", "Connection", "X-Real-IP", "X-Forwarded-For", "X-Forwarded-Host", "X-Forwarded-Port",
+	"X-Forwarded-Proto", "X-Forwarded-Scheme", "X-Scheme", "X-Original-Forwarded-For",
+}
+
+var headerName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*$`)
+
+type Header struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type Config struct {
+	ProxySetHeaders     []Header `json:"proxy-set-headers"`
+	AddResponseHeaders  []Header `json:"add-response-headers"`
+	HideUpstreamHeaders []string `json:"hide-upstream-headers"`
+	UpstreamVhost       string   `json:"upstream-vhost"`
+}
+
+type headers struct {
+	r resolver.Resolver
+}
+
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return &headers{r: r}
+}
+
+func (h *headers) Parse(ing *ingressv1.Ingress) (interface{}, error) {
+	config := &Config{}
+
+	cm, err := parser.GetStringAnnotation(proxySetHeaders, ing, headerAnnotations.Annotations)
+	if err != nil {
+		if errors.IsValidationError(err) {
+			klog.Warningf("%s is invalid, defaulting to empty", proxySetHeaders)
+		}
+	}
+	if cm != "" {
+		if config.ProxySetHeaders, err = h.configMapHeaders(cm); err != nil {
+			return nil, err
+		}
+	}
+
+	add, err := parser.GetStringAnnotation(addResponseHeaders, ing, headerAnnotations.Annotations)
+	if err != nil {
+		if errors.IsValidationError(err) {
+			klog.Warningf("%s is invalid, defaulting to empty", addResponseHeaders)
+		}
+	}
+	if add != "" {
+		if config.AddResponseHeaders, err = parseHeaderLines(add); err != nil {
+			return nil, errors.NewInvalidAnnotationsContentError(addResponseHeaders, err.Error())
+		}
+	}
+
+	hide, err := parser.GetStringAnnotation(hideUpstreamHeaders, ing, headerAnnotations.Annotations)
+	if err != nil {
+		if errors.IsValidationError(err) {
+			klog.Warningf("%s is invalid, defaulting to empty", hideUpstreamHeaders)
+		}
+	}
+	for _, v := range strings.Split(hide, ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+		if !headerName.MatchString(v) {
+			return nil, errors.NewInvalidAnnotationsContentError(hideUpstreamHeaders, v)
+		}
+		config.HideUpstreamHeaders = append(config.HideUpstreamHeaders, v)
+	}
+
+	config.UpstreamVhost, err = parser.GetStringAnnotation(upstreamVhost, ing, headerAnnotations.Annotations)
+	if err != nil {
+		if errors.IsValidationError(err) {
+			klog.Warningf("%s is invalid, defaulting to empty", upstreamVhost)
+		}
+	}
+	if config.UpstreamVhost != "" && !isValidVhost(config.UpstreamVhost) {
+		return nil, errors.NewInvalidAnnotationsContentError(upstreamVhost, config.UpstreamVhost)
+	}
+
+	return config, nil
+}
+
+func (h *headers) Validate(anns map[string]string) error {
+	return parser.CheckAnnotations(anns, headerAnnotations.Annotations)
+}
+
+// configMapHeaders reads the proxy-set-headers ConfigMap, sorted by header so the rendered configuration is stable.
+func (h *headers) configMapHeaders(name string) ([]Header, error) {
+	if h.r == nil {
+		return nil, errors.NewNotSatisfiableError(fmt.Sprintf("configmap: %s of %s can't be read", name, proxySetHeaders))
+	}
+
+	cm, err := h.r.GetConfigMap(name)
+	if err != nil {
+		return nil, errors.NewNotSatisfiableError(err.Error())
+	}
+
+	var list []Header
+	for k, v := range cm.Data {
+		if err := checkHeader(k, v); err != nil {
+			return nil, errors.NewInvalidAnnotationsContentError(proxySetHeaders, fmt.Sprintf("%s, %v", name, err))
+		}
+
+		for _, r := range reservedHeaders {
+			if strings.EqualFold(k, r) {
+				return nil, errors.NewInvalidAnnotationsContentError(proxySetHeaders, fmt.Sprintf("%s, header %s is set by the controller", name, k))
+			}
+		}
+
+		list = append(list, Header{Name: k, Value: v})
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+
+	return list, nil
+}
+
+// ConfigMapRef returns the name of the proxy-set-headers ConfigMap of an ingress, empty when it has none.
+func ConfigMapRef(anns map[string]string) string {
+	return anns[parser.GetAnnotationWithPrefix(proxySetHeaders)]
+}
+
+func parseHeaderLines(s string) ([]Header, error) {
+	var list []Header
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("%s is not a header: value pair", line)
+		}
+
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if err := checkHeader(name, value); err != nil {
+			return nil, err
+		}
+
+		list = append(list, Header{Name: name, Value: value})
+	}
+
+	return list, nil
+}
+
+// checkHeader keeps names to the usual header tokens and values from leaving the quoted nginx argument they are
+// rendered into, nginx variables are allowed in values. The rendered server is parsed again as a template, so
+// values can't contain {{ or }} either.
+func checkHeader(name, value string) error {
+	if !headerName.MatchString(name) {
+		return fmt.Errorf("invalid header name %s", name)
+	}
+
+	if strings.Contains(value, "{{") || strings.Contains(value, "}}") {
+		return fmt.Errorf("value of header %s can't contain {{ or }}", name)
+	}
+
+	for _, c := range value {
+		if c < 0x20 || c == 0x7f || c == '"' || c == '\\' {
+			return fmt.Errorf("invalid value of header %s", name)
+		}
+	}
+
+	return nil
+}
+
+func isValidVhost(s string) bool {
+	host := s
+	if h, port, err := net.SplitHostPort(s); err == nil {
+		if n, err := strconv.Atoi(port); err != nil || len(validation.IsValidPortNum(n)) > 0 {
+			return false
+		}
+		host = h
+	}
+
+	return net.ParseIP(host) != nil || len(validation.IsDNS1123Subdomain(host)) == 0
+}
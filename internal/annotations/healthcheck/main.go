@@ -0,0 +1,196 @@
+package healthcheck
+
+import (
+	"fmt"
+	ingressv1 "github.com/ingoxx/ingress-nginx-kubebuilder/api/v1"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/parser"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/resolver"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/config"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/errors"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/health"
+	"k8s.io/klog/v2"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	healthCheckPath     = "health-check-path"
+	healthCheckStatus   = "health-check-status"
+	healthCheckTimeout  = "health-check-timeout"
+	healthCheckInterval = "health-check-interval"
+	healthyThreshold    = "health-check-healthy-threshold"
+	unhealthyThreshold  = "health-check-unhealthy-threshold"
+)
+
+// useLb is the weight annotation merging the backends into one upstream, its endpoints are not rendered.
+const useLb = "use-lb"
+
+const (
+	defaultStatus         = "200-399"
+	defaultTimeout        = 2
+	defaultInterval       = 10
+	defaultHealthyCount   = 2
+	defaultUnhealthyCount = 3
+)
+
+var healthCheckAnnotations = parser.Annotation{
+	Group: "healthCheck",
+	Annotations: parser.AnnotationFields{
+		healthCheckPath: {
+			Doc: "path requested from every endpoint of the backends by the controller, the failing endpoints are taken out, " +
+				"needs --enable-health-checks, e.g: `/healthz`, optional",
+		},
+		healthCheckStatus: {
+			Doc: "status codes of a healthy endpoint, a code or a range, defaults to `200-399`, optional",
+		},
+		healthCheckTimeout: {
+			Doc: "seconds a probe may take, defaults to 2, optional",
+		},
+		healthCheckInterval: {
+			Doc: "seconds between two probes of an endpoint, defaults to 10, optional",
+		},
+		healthyThreshold: {
+			Doc: "successful probes in a row bringing an endpoint back, defaults to 2, optional",
+		},
+		unhealthyThreshold: {
+			Doc: "failed probes in a row taking an endpoint out, defaults to 3, optional",
+		},
+	},
+}
+
+// Config is empty unless the controller runs the prober and the ingress sets health-check-path.
+type Config struct {
+	Path      string `json:"path"`
+	StatusMin int    `json:"status-min"`
+	StatusMax int    `json:"status-max"`
+	Timeout   int    `json:"timeout"`
+	Interval  int    `json:"interval"`
+	Healthy   int    `json:"healthy-threshold"`
+	Unhealthy int    `json:"unhealthy-threshold"`
+}
+
+// Check returns the probing settings of the prober.
+func (c Config) Check() health.Check {
+	return health.Check{
+		Path:      c.Path,
+		StatusMin: c.StatusMin,
+		StatusMax: c.StatusMax,
+		Timeout:   time.Duration(c.Timeout) * time.Second,
+		Interval:  time.Duration(c.Interval) * time.Second,
+		Healthy:   c.Healthy,
+		Unhealthy: c.Unhealthy,
+	}
+}
+
+type healthCheck struct {
+	r resolver.Resolver
+}
+
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return &healthCheck{}
+}
+
+func (h *healthCheck) Parse(ing *ingressv1.Ingress) (interface{}, error) {
+	path, err := parser.GetStringAnnotation(healthCheckPath, ing, healthCheckAnnotations.Annotations)
+	if err != nil {
+		if errors.IsValidationError(err) {
+			klog.Warningf("%s is invalid, defaulting to empty", healthCheckPath)
+		}
+	}
+
+	if path == "" {
+		return &Config{}, nil
+	}
+
+	if !config.HealthChecks {
+		klog.Warningf("ingress: %s, namespace: %s sets %s, but health checks are not enabled in the controller", ing.Name, ing.Namespace, healthCheckPath)
+		return &Config{}, nil
+	}
+
+	if !parser.IsValidPath(path) {
+		return nil, errors.NewInvalidAnnotationsContentError(healthCheckPath, path)
+	}
+
+	if lb, _ := strconv.ParseBool(ing.GetAnnotations()[parser.GetAnnotationWithPrefix(useLb)]); lb {
+		return nil, errors.NewInvalidAnnotationsContentError(healthCheckPath, path+", the endpoints of "+useLb+" upstreams are not probed")
+	}
+
+	cfg := &Config{
+		Path:      path,
+		Timeout:   defaultTimeout,
+		Interval:  defaultInterval,
+		Healthy:   defaultHealthyCount,
+		Unhealthy: defaultUnhealthyCount,
+	}
+
+	status, err := parser.GetStringAnnotation(healthCheckStatus, ing, healthCheckAnnotations.Annotations)
+	if err != nil {
+		if errors.IsValidationError(err) {
+			klog.Warningf("%s is invalid, defaulting to %s", healthCheckStatus, defaultStatus)
+		}
+	}
+	if status == "" {
+		status = defaultStatus
+	}
+	if cfg.StatusMin, cfg.StatusMax, err = parseStatus(status); err != nil {
+		return nil, errors.NewInvalidAnnotationsContentError(healthCheckStatus, status)
+	}
+
+	ints := map[string]*int{
+		healthCheckTimeout:  &cfg.Timeout,
+		healthCheckInterval: &cfg.Interval,
+		healthyThreshold:    &cfg.Healthy,
+		unhealthyThreshold:  &cfg.Unhealthy,
+	}
+	for name, field := range ints {
+		v, err := parser.GetIntAnnotation(name, ing, healthCheckAnnotations.Annotations)
+		if err == nil {
+			if v < 1 {
+				return nil, errors.NewInvalidAnnotationsContentError(name, fmt.Sprintf("%d is not positive", v))
+			}
+			*field = v
+		} else if errors.IsInvalidContentError(err) {
+			return nil, err
+		}
+	}
+
+	if cfg.Timeout >= cfg.Interval {
+		return nil, errors.NewInvalidAnnotationsContentError(healthCheckTimeout, fmt.Sprintf("%d, it must be shorter than the %d seconds %s", cfg.Timeout, cfg.Interval, healthCheckInterval))
+	}
+
+	return cfg, nil
+}
+
+func (h *healthCheck) Validate(anns map[string]string) error {
+	return parser.CheckAnnotations(anns, healthCheckAnnotations.Annotations)
+}
+
+// Enabled reports whether the endpoints of an ingress with anns are probed.
+func Enabled(anns map[string]string) bool {
+	return config.HealthChecks && anns[parser.GetAnnotationWithPrefix(healthCheckPath)] != ""
+}
+
+// parseStatus reads a status code, e.g. 200, or a range, e.g. 200-399.
+func parseStatus(s string) (int, int, error) {
+	first, last, isRange := strings.Cut(s, "-")
+	if !isRange {
+		last = first
+	}
+
+	low, err := strconv.Atoi(strings.TrimSpace(first))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	high, err := strconv.Atoi(strings.TrimSpace(last))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if low < 100 || high > 599 || low > high {
+		return 0, 0, fmt.Errorf("invalid status range %s", s)
+	}
+
+	return low, high, nil
+}
@@ -7,26 +7,38 @@ import (
 	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
+	"slices"
 	"strings"
 )
 
 const (
-	allowListAnnotation = "allowList"
+	allowListAnnotation      = "allowList"
+	allowListPathsAnnotation = "allowlist-paths"
 )
 
 type ipallowList struct {
 	r resolver.Resolver
 }
 
+// SourceRange is rendered into the locations of Paths, every location of the ingress when it is empty.
 type SourceRange struct {
-	CIDR []string `json:"cidr,omitempty"`
+	CIDR  []string `json:"cidr,omitempty"`
+	Paths []string `json:"paths,omitempty"`
+}
+
+// Applies reports whether the list is rendered into the location of path.
+func (s SourceRange) Applies(path string) bool {
+	return len(s.CIDR) > 0 && (len(s.Paths) == 0 || slices.Contains(s.Paths, path))
 }
 
 var ipAllowListAnnotations = parser.Annotation{
 	Group: "ipallowlist",
 	Annotations: parser.AnnotationFields{
 		allowListAnnotation: {
-			Doc: "allow ip, e.g: `allow 1.1.1.1` , required",
+			Doc: "comma separated IPv4 or IPv6 addresses and CIDRs allowed to access the ingress, the others are refused, e.g: `10.0.0.0/16,2001:db8::/32`",
+		},
+		allowListPathsAnnotation: {
+			Doc: "comma separated paths of the ingress the allow list applies to, every path when it is not set, e.g: `/admin,/metrics`",
 		},
 	},
 }
@@ -36,7 +48,7 @@ func NewParser(r resolver.Resolver) parser.IngressAnnotation {
 }
 
 // Parse Only valid for the backend within the current ingress rule
-// e.g. 10.0.0.8/16,11.0.0.9/16
+// e.g. 10.0.0.8/16,11.0.0.9/16,2001:db8::/32
 func (p *ipallowList) Parse(ing *ingressv1.Ingress) (interface{}, error) {
 	val, err := parser.GetStringAnnotation(allowListAnnotation, ing, ipAllowListAnnotations.Annotations)
 	if err != nil {
@@ -51,18 +63,28 @@ func (p *ipallowList) Parse(ing *ingressv1.Ingress) (interface{}, error) {
 		if alias == "" {
 			continue
 		}
-		if !parser.IsIp(alias) {
+
+		cidr, ok := parser.ParseSourceRange(alias)
+		if !ok {
 			return nil, errors.NewInvalidAnnotationsContentError(allowListAnnotation, alias)
 		}
 
-		if !aliases.Has(alias) {
-			aliases.Insert(alias)
+		aliases.Insert(cidr)
+	}
+
+	paths, err := parser.GetStringAnnotation(allowListPathsAnnotation, ing, ipAllowListAnnotations.Annotations)
+	if err != nil {
+		if errors.IsValidationError(err) {
+			klog.Warningf("%s is invalid, defaulting to every path", allowListPathsAnnotation)
 		}
 	}
 
-	l := aliases.List()
+	l, err := parser.ParsePaths(allowListPathsAnnotation, paths)
+	if err != nil {
+		return nil, err
+	}
 
-	return &SourceRange{l}, nil
+	return &SourceRange{CIDR: aliases.List(), Paths: l}, nil
 }
 
 func (p *ipallowList) Validate(anns map[string]string) error {
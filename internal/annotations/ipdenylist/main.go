@@ -7,26 +7,38 @@ import (
 	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
+	"slices"
 	"strings"
 )
 
 const (
-	denyListAnnotation = "denyList"
+	denyListAnnotation      = "denyList"
+	denyListPathsAnnotation = "denylist-paths"
 )
 
 type ipdenyList struct {
 	r resolver.Resolver
 }
 
+// SourceRange is rendered into the locations of Paths, every location of the ingress when it is empty.
 type SourceRange struct {
-	CIDR []string `json:"cidr,omitempty"`
+	CIDR  []string `json:"cidr,omitempty"`
+	Paths []string `json:"paths,omitempty"`
+}
+
+// Applies reports whether the list is rendered into the location of path.
+func (s SourceRange) Applies(path string) bool {
+	return len(s.CIDR) > 0 && (len(s.Paths) == 0 || slices.Contains(s.Paths, path))
 }
 
 var ipDenyListAnnotations = parser.Annotation{
 	Group: "ipdenylist",
 	Annotations: parser.AnnotationFields{
 		denyListAnnotation: {
-			Doc: "deny ip, e.g: `2.2.2.2`, required",
+			Doc: "comma separated IPv4 or IPv6 addresses and CIDRs refused access to the ingress, e.g: `2.2.2.2,2001:db8::1`",
+		},
+		denyListPathsAnnotation: {
+			Doc: "comma separated paths of the ingress the deny list applies to, every path when it is not set, e.g: `/admin,/metrics`",
 		},
 	},
 }
@@ -49,18 +61,28 @@ func (p *ipdenyList) Parse(ing *ingressv1.Ingress) (interface{}, error) {
 		if alias == "" {
 			continue
 		}
-		if !parser.IsIp(alias) {
+
+		cidr, ok := parser.ParseSourceRange(alias)
+		if !ok {
 			return nil, errors.NewInvalidAnnotationsContentError(denyListAnnotation, alias)
 		}
 
-		if !aliases.Has(alias) {
-			aliases.Insert(alias)
+		aliases.Insert(cidr)
+	}
+
+	paths, err := parser.GetStringAnnotation(denyListPathsAnnotation, ing, ipDenyListAnnotations.Annotations)
+	if err != nil {
+		if errors.IsValidationError(err) {
+			klog.Warningf("%s is invalid, defaulting to every path", denyListPathsAnnotation)
 		}
 	}
 
-	l := aliases.List()
+	l, err := parser.ParsePaths(denyListPathsAnnotation, paths)
+	if err != nil {
+		return nil, err
+	}
 
-	return &SourceRange{l}, nil
+	return &SourceRange{CIDR: aliases.List(), Paths: l}, nil
 }
 
 func (p *ipdenyList) Validate(anns map[string]string) error {
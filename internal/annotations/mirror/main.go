@@ -0,0 +1,195 @@
+package mirror
+
+import (
+	"fmt"
+	ingressv1 "github.com/ingoxx/ingress-nginx-kubebuilder/api/v1"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/parser"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/resolver"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/klog/v2"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	mirrorTarget      = "mirror-target"
+	mirrorRequestBody = "mirror-request-body"
+	mirrorHost        = "mirror-host"
+)
+
+var mirrorAnnotations = parser.Annotation{
+	Group: "mirror",
+	Annotations: parser.AnnotationFields{
+		mirrorTarget: {
+			Doc: "copy every request to a Service of the ingress namespace, `name` or `name:port`, or to an url, e.g: `https://shadow.example.com`, " +
+				"the responses of the copies are discarded, optional",
+		},
+		mirrorRequestBody: {
+			Doc: "send the request body with the copies, defaults to true, optional",
+		},
+		mirrorHost: {
+			Doc: "Host header of the copies, defaults to the host of the url target or to the requested host, optional",
+		},
+	},
+}
+
+// Config is the target the requests are mirrored to, its upstream is the server Address reached over Scheme.
+type Config struct {
+	Target  string `json:"target"`
+	Scheme  string `json:"scheme"`
+	Address string `json:"address"`
+	// URI is the path of an url target, the copies keep the request uri without one
+	URI string `json:"uri"`
+	// SSLName is the server name sent to an https url target
+	SSLName     string `json:"ssl-name"`
+	Host        string `json:"host"`
+	RequestBody bool   `json:"request-body"`
+}
+
+type mirror struct {
+	r resolver.Resolver
+}
+
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return &mirror{r: r}
+}
+
+func (m *mirror) Parse(ing *ingressv1.Ingress) (interface{}, error) {
+	cfg := &Config{RequestBody: true}
+
+	var err error
+	cfg.Target, err = parser.GetStringAnnotation(mirrorTarget, ing, mirrorAnnotations.Annotations)
+	if err != nil {
+		if errors.IsValidationError(err) {
+			klog.Warningf("%s is invalid, defaulting to empty", mirrorTarget)
+		}
+	}
+
+	if cfg.Target == "" {
+		return cfg, nil
+	}
+
+	if strings.Contains(cfg.Target, "://") {
+		err = m.parseURL(cfg, ing)
+	} else {
+		err = m.parseService(cfg, ing)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := parser.GetBoolAnnotations(mirrorRequestBody, ing, mirrorAnnotations.Annotations)
+	if err == nil {
+		cfg.RequestBody = body
+	} else if errors.IsInvalidContentError(err) {
+		return nil, err
+	}
+
+	host, err := parser.GetStringAnnotation(mirrorHost, ing, mirrorAnnotations.Annotations)
+	if err != nil {
+		if errors.IsValidationError(err) {
+			klog.Warningf("%s is invalid, defaulting to %s", mirrorHost, cfg.Host)
+		}
+	}
+	if host != "" {
+		if net.ParseIP(host) == nil && len(validation.IsDNS1123Subdomain(host)) > 0 {
+			return nil, errors.NewInvalidAnnotationsContentError(mirrorHost, host)
+		}
+		cfg.Host = host
+	}
+
+	return cfg, nil
+}
+
+func (m *mirror) Validate(anns map[string]string) error {
+	return parser.CheckAnnotations(anns, mirrorAnnotations.Annotations)
+}
+
+func (m *mirror) parseURL(cfg *Config, ing *ingressv1.Ingress) error {
+	u, err := url.Parse(cfg.Target)
+	if err != nil || !parser.IsValidRedirectURL(cfg.Target) || u.RawQuery != "" || u.Fragment != "" {
+		return errors.NewInvalidAnnotationsContentError(mirrorTarget, cfg.Target)
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	if svc := backendService(ing, u.Hostname()); svc != "" {
+		return errors.NewInvalidAnnotationsContentError(mirrorTarget, fmt.Sprintf("%s, service: %s is a backend of the ingress", cfg.Target, svc))
+	}
+
+	cfg.Scheme, cfg.Address, cfg.URI = u.Scheme, net.JoinHostPort(u.Hostname(), port), u.EscapedPath()
+	cfg.SSLName, cfg.Host = u.Hostname(), u.Hostname()
+	if cfg.URI == "/" {
+		cfg.URI = ""
+	}
+
+	return nil
+}
+
+// parseService resolves name or name:port of the ingress namespace, the first port of the Service is used when
+// none is given.
+func (m *mirror) parseService(cfg *Config, ing *ingressv1.Ingress) error {
+	name, port, _ := strings.Cut(cfg.Target, ":")
+	if len(validation.IsDNS1035Label(name)) > 0 {
+		return errors.NewInvalidAnnotationsContentError(mirrorTarget, cfg.Target)
+	}
+
+	if svc := backendService(ing, name+"."+ing.Namespace); svc != "" {
+		return errors.NewInvalidAnnotationsContentError(mirrorTarget, fmt.Sprintf("%s, service: %s is a backend of the ingress", cfg.Target, svc))
+	}
+
+	if m.r == nil {
+		return errors.NewNotSatisfiableError(fmt.Sprintf("service: %s of %s can't be read", name, mirrorTarget))
+	}
+
+	svc, err := m.r.GetService(name)
+	if err != nil {
+		return errors.NewNotSatisfiableError(err.Error())
+	}
+
+	for _, p := range svc.Spec.Ports {
+		if port == "" || port == p.Name || port == strconv.Itoa(int(p.Port)) {
+			cfg.Scheme, cfg.Address, cfg.Host = "http", fmt.Sprintf("%s.%s.svc:%d", name, ing.Namespace, p.Port), "$host"
+			return nil
+		}
+	}
+
+	return errors.NewNotSatisfiableError(fmt.Sprintf("port: %s of %s not exists in service: %s, namespace: %s", port, mirrorTarget, name, ing.Namespace))
+}
+
+// backendService returns the backend Service of the ingress host names, in the name.namespace form optionally
+// followed by .svc and the cluster domain, empty when host is none of them.
+func backendService(ing *ingressv1.Ingress, host string) string {
+	var names []string
+	if ing.Spec.DefaultBackend != nil && ing.Spec.DefaultBackend.Service != nil {
+		names = append(names, ing.Spec.DefaultBackend.Service.Name)
+	}
+	for _, r := range ing.Spec.Rules {
+		if r.HTTP == nil {
+			continue
+		}
+		for _, p := range r.HTTP.Paths {
+			if p.Backend.Service != nil {
+				names = append(names, p.Backend.Service.Name)
+			}
+		}
+	}
+
+	host = strings.TrimSuffix(strings.TrimSuffix(strings.ToLower(host), ".cluster.local"), ".svc")
+	for _, n := range names {
+		if host == n+"."+ing.Namespace {
+			return n
+		}
+	}
+
+	return ""
+}
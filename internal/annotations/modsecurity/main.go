@@ -0,0 +1,191 @@
+package modsecurity
+
+import (
+	"fmt"
+	ingressv1 "github.com/ingoxx/ingress-nginx-kubebuilder/api/v1"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/parser"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/resolver"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/snippet"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/config"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/errors"
+	"k8s.io/klog/v2"
+	"path"
+	"regexp"
+	"strings"
+)
+
+const (
+	enableModSecurity    = "enable-modsecurity"
+	enableOWASPCoreRules = "enable-owasp-core-rules"
+	transactionID        = "modsecurity-transaction-id"
+	modSecuritySnippet   = "modsecurity-snippet"
+)
+
+// SnippetKey is the key of the modsecurity-snippet ConfigMap holding the rules.
+const SnippetKey = "snippet"
+
+var modSecurityAnnotations = parser.Annotation{
+	Group: "modsecurity",
+	Annotations: parser.AnnotationFields{
+		enableModSecurity: {
+			Doc: "enables the ModSecurity WAF in the locations of the ingress with the rules of --modsecurity-rules-file, e.g: `true`, optional",
+		},
+		enableOWASPCoreRules: {
+			Doc: "adds the OWASP core rule set of --owasp-core-rules-file, needs enable-modsecurity, e.g: `true`, optional",
+		},
+		transactionID: {
+			Doc: "transaction id of the ModSecurity logs, an nginx variable or a string, e.g: `$request_id`, optional",
+		},
+		modSecuritySnippet: {
+			Doc: "name of a ConfigMap in the ingress namespace whose `snippet` key holds ModSecurity rules added to the locations, needs enable-modsecurity and --allow-snippet-annotations, optional",
+		},
+	},
+}
+
+var transactionIDValue = regexp.MustCompile(`^[A-Za-z0-9_$.-]+$`)
+
+// blockedRules can't be set by the modsecurity-snippet rules, they read or write files as the nginx master process
+// or turn the WAF off.
+var blockedRules = []string{"include", "secauditlog*", "secdebuglog*", "secruleengine"}
+
+// Config renders the ModSecurity WAF into the locations of the ingress, the RulesFiles first and then the Snippet.
+type Config struct {
+	Enable        bool     `json:"enable"`
+	OWASPRules    bool     `json:"owasp-rules"`
+	TransactionID string   `json:"transaction-id,omitempty"`
+	RulesFiles    []string `json:"rules-files,omitempty"`
+	Snippet       string   `json:"snippet,omitempty"`
+}
+
+type modSecurity struct {
+	r resolver.Resolver
+}
+
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return &modSecurity{r: r}
+}
+
+func (m *modSecurity) Parse(ing *ingressv1.Ingress) (interface{}, error) {
+	cfg := &Config{}
+
+	var err error
+	cfg.Enable, err = parser.GetBoolAnnotations(enableModSecurity, ing, modSecurityAnnotations.Annotations)
+	if err != nil && errors.IsInvalidContentError(err) {
+		return nil, err
+	}
+
+	cfg.OWASPRules, err = parser.GetBoolAnnotations(enableOWASPCoreRules, ing, modSecurityAnnotations.Annotations)
+	if err != nil && errors.IsInvalidContentError(err) {
+		return nil, err
+	}
+
+	cfg.TransactionID, err = parser.GetStringAnnotation(transactionID, ing, modSecurityAnnotations.Annotations)
+	if err != nil {
+		if errors.IsValidationError(err) {
+			klog.Warningf("%s is invalid, defaulting to empty", transactionID)
+		}
+	}
+	if cfg.TransactionID != "" && !transactionIDValue.MatchString(cfg.TransactionID) {
+		return nil, errors.NewInvalidAnnotationsContentError(transactionID, cfg.TransactionID)
+	}
+
+	cm, err := parser.GetStringAnnotation(modSecuritySnippet, ing, modSecurityAnnotations.Annotations)
+	if err != nil {
+		if errors.IsValidationError(err) {
+			klog.Warningf("%s is invalid, defaulting to empty", modSecuritySnippet)
+		}
+	}
+
+	if !cfg.Enable {
+		if cfg.OWASPRules || cm != "" {
+			klog.Warningf("%s and %s are ignored without %s in ingress: %s, namespace: %s",
+				enableOWASPCoreRules, modSecuritySnippet, enableModSecurity, ing.Name, ing.Namespace)
+		}
+		return &Config{}, nil
+	}
+
+	cfg.RulesFiles = []string{config.ModSecurityRulesFile}
+	if cfg.OWASPRules {
+		cfg.RulesFiles = append(cfg.RulesFiles, config.OWASPCoreRulesFile)
+	}
+
+	if cm != "" {
+		if !snippet.Allowed(ing.Namespace) {
+			err = errors.NewRiskyAnnotationsError(fmt.Sprintf("%s is not allowed in namespace %s, see --allow-snippet-annotations and --snippet-namespaces", modSecuritySnippet, ing.Namespace))
+			klog.ErrorS(err, fmt.Sprintf("ingress: %s, namespace: %s", ing.Name, ing.Namespace))
+			return nil, err
+		}
+
+		if cfg.Snippet, err = m.snippet(cm); err != nil {
+			return nil, err
+		}
+	}
+
+	return cfg, nil
+}
+
+// Validate refuses modsecurity-snippet when the snippets are disabled, the ingress is then rejected as risky.
+func (m *modSecurity) Validate(anns map[string]string) error {
+	if err := parser.CheckAnnotations(anns, modSecurityAnnotations.Annotations); err != nil {
+		return err
+	}
+
+	if _, ok := anns[parser.GetAnnotationWithPrefix(modSecuritySnippet)]; ok && !config.AllowSnippetAnnotations {
+		return fmt.Errorf("%s is disabled, see --allow-snippet-annotations", modSecuritySnippet)
+	}
+
+	return nil
+}
+
+// snippet reads the rules of the modsecurity-snippet ConfigMap, escaped for the single quoted modsecurity_rules.
+func (m *modSecurity) snippet(name string) (string, error) {
+	if m.r == nil {
+		return "", errors.NewNotSatisfiableError(fmt.Sprintf("configmap: %s of %s can't be read", name, modSecuritySnippet))
+	}
+
+	cm, err := m.r.GetConfigMap(name)
+	if err != nil {
+		return "", errors.NewNotSatisfiableError(err.Error())
+	}
+
+	rules, ok := cm.Data[SnippetKey]
+	if !ok || strings.TrimSpace(rules) == "" {
+		return "", errors.NewInvalidAnnotationsContentError(modSecuritySnippet, fmt.Sprintf("%s, key %s is missing or empty", name, SnippetKey))
+	}
+
+	// the rendered servers are parsed again as a template
+	if strings.Contains(rules, "{{") || strings.Contains(rules, "}}") {
+		return "", errors.NewInvalidAnnotationsContentError(modSecuritySnippet, fmt.Sprintf("%s, %s can't contain {{ or }}", name, SnippetKey))
+	}
+
+	if err := CheckRules(rules); err != nil {
+		return "", errors.NewRiskyAnnotationsError(fmt.Sprintf("%s, %s: %v", modSecuritySnippet, name, err))
+	}
+
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(strings.TrimSpace(rules)), nil
+}
+
+// CheckRules checks the first word of every line against the blocked rules, the lines continuing a rule included
+// since a continued line can't be told apart from a directive without parsing the rules like ModSecurity does.
+func CheckRules(rules string) error {
+	for _, line := range strings.Split(rules, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		name := strings.ToLower(strings.Trim(fields[0], `"'\`))
+		for _, b := range blockedRules {
+			if ok, _ := path.Match(b, name); ok {
+				return fmt.Errorf("directive %s is not allowed", fields[0])
+			}
+		}
+	}
+
+	return nil
+}
+
+// ConfigMapRef returns the name of the modsecurity-snippet ConfigMap of an ingress, empty when it has none.
+func ConfigMapRef(anns map[string]string) string {
+	return anns[parser.GetAnnotationWithPrefix(modSecuritySnippet)]
+}
@@ -0,0 +1,35 @@
+package modsecurity
+
+import (
+	"testing"
+)
+
+func TestCheckRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   string
+		wantErr bool
+	}{
+		{name: "rule", rules: `SecRule ARGS "@rx attack" "id:1,phase:2,deny"`},
+		{name: "continued rule", rules: "SecRule ARGS \"@rx attack\" \\\n    \"id:1,phase:2,deny\""},
+		{name: "comment", rules: "# Include /etc/passwd\nSecRuleRemoveById 920350"},
+		{name: "include", rules: "Include /etc/passwd", wantErr: true},
+		{name: "include lower case", rules: "include /etc/passwd", wantErr: true},
+		{name: "quoted include", rules: `"Include" /etc/passwd`, wantErr: true},
+		{name: "indented include", rules: "SecRuleRemoveById 1\n   Include /etc/passwd", wantErr: true},
+		{name: "audit log", rules: "SecAuditLog /etc/nginx/nginx.conf", wantErr: true},
+		{name: "audit log storage", rules: "SecAuditLogStorageDir /tmp", wantErr: true},
+		{name: "debug log", rules: "SecDebugLog /etc/nginx/nginx.conf", wantErr: true},
+		{name: "debug log level", rules: "SecDebugLogLevel 9", wantErr: true},
+		{name: "rule engine", rules: "SecRuleEngine Off", wantErr: true},
+		{name: "rule remove", rules: "SecRuleRemoveById 949110"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckRules(tt.rules); (err != nil) != tt.wantErr {
+				t.Errorf("CheckRules(%q) error = %v, wantErr %v", tt.rules, err, tt.wantErr)
+			}
+		})
+	}
+}
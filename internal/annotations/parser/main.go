@@ -4,7 +4,6 @@ import (
 	"fmt"
 	ingressv1 "github.com/ingoxx/ingress-nginx-kubebuilder/api/v1"
 	kerr "github.com/ingoxx/ingress-nginx-kubebuilder/internal/errors"
-	"regexp"
 	"strconv"
 	"strings"
 )
@@ -83,6 +82,18 @@ func (a ingAnnotations) parseBool(name string) (bool, error) {
 	return false, kerr.ErrMissingAnnotations
 }
 
+func (a ingAnnotations) parseInt(name string) (int, error) {
+	val, ok := a[name]
+	if ok {
+		i, err := strconv.Atoi(val)
+		if err != nil {
+			return 0, kerr.NewInvalidContent(name, val)
+		}
+		return i, nil
+	}
+	return 0, kerr.ErrMissingAnnotations
+}
+
 func GetStringAnnotation(name string, ing *ingressv1.Ingress, field AnnotationFields) (string, error) {
 	key, err := CheckAnnotationsKey(name, ing, field)
 	if err != nil {
@@ -99,13 +110,10 @@ func GetBoolAnnotations(name string, ing *ingressv1.Ingress, field AnnotationFie
 	return ingAnnotations(ing.GetAnnotations()).parseBool(key)
 }
 
-func GetDnsRegex(str string) string {
-	p := `([a0-z9]+\.)+([a-z]+)`
-	matched := regexp.MustCompile(p)
-	dns := matched.FindStringSubmatch(str)
-	if len(dns) == 0 {
-		return ""
+func GetIntAnnotation(name string, ing *ingressv1.Ingress, field AnnotationFields) (int, error) {
+	key, err := CheckAnnotationsKey(name, ing, field)
+	if err != nil {
+		return 0, err
 	}
-
-	return dns[0]
+	return ingAnnotations(ing.GetAnnotations()).parseInt(key)
 }
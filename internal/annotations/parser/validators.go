@@ -5,7 +5,11 @@ import (
 	"fmt"
 	ingressv1 "github.com/ingoxx/ingress-nginx-kubebuilder/api/v1"
 	kerr "github.com/ingoxx/ingress-nginx-kubebuilder/internal/errors"
+	"net/netip"
+	"net/url"
 	"regexp"
+	"slices"
+	"strings"
 )
 
 type AnnotationValidator func(string) error
@@ -28,19 +32,82 @@ func IsNonEmptyParenthesesRegex(str string) bool {
 	return matched
 }
 
+// IsIp reports whether target is an IPv4 or IPv6 address.
 func IsIp(target string) bool {
-	pattern := `^(\d+).((\d+).){2}(\d+)$`
-	re := regexp.MustCompile(pattern)
-	return re.MatchString(target)
+	_, err := netip.ParseAddr(target)
+	return err == nil
+}
+
+// ParseSourceRange returns the canonical form of an IPv4 or IPv6 address or CIDR, the host bits of a CIDR are cleared
+// so that nginx doesn't warn about them.
+func ParseSourceRange(s string) (string, bool) {
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return "", false
+		}
+
+		return p.Masked().String(), true
+	}
+
+	a, err := netip.ParseAddr(s)
+	if err != nil || a.Zone() != "" {
+		return "", false
+	}
+
+	return a.String(), true
 }
 
 func IsValidHost(host string) bool {
-	pattern := `([a0-z9]+\.)+([a-z]+)`
-	matched := regexp.MustCompile(pattern)
-	if !matched.MatchString(host) {
+	return ingressv1.IsValidHost(host)
+}
+
+// unsafeChars can't appear unquoted in an nginx directive or would be expanded as a variable.
+const unsafeChars = " \t\r\n;{}\"'\\$#"
+
+// IsValidRedirectURL reports whether s is an absolute http or https url that can be rendered into a return directive.
+func IsValidRedirectURL(s string) bool {
+	if strings.ContainsAny(s, unsafeChars) {
 		return false
 	}
-	return true
+
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" || u.User != nil {
+		return false
+	}
+
+	return !strings.ContainsAny(u.Hostname(), "*!&'()+,=")
+}
+
+// IsValidPath reports whether s is an absolute path that can be rendered into a location or a return directive.
+func IsValidPath(s string) bool {
+	if !strings.HasPrefix(s, "/") || strings.ContainsAny(s, unsafeChars) {
+		return false
+	}
+
+	_, err := url.ParseRequestURI(s)
+	return err == nil
+}
+
+// ParsePaths returns the deduplicated comma separated paths of the name annotation.
+func ParsePaths(name, val string) ([]string, error) {
+	var paths []string
+	for _, p := range strings.Split(val, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+
+		if !strings.HasPrefix(p, "/") || strings.ContainsAny(p, unsafeChars) {
+			return nil, kerr.NewInvalidAnnotationsContentError(name, p)
+		}
+
+		if !slices.Contains(paths, p) {
+			paths = append(paths, p)
+		}
+	}
+
+	return paths, nil
 }
 
 func IsAnnotationsPrefix(annotation string) bool {
@@ -0,0 +1,82 @@
+package annotations
+
+import (
+	"fmt"
+	ingressv1 "github.com/ingoxx/ingress-nginx-kubebuilder/api/v1"
+	kerr "github.com/ingoxx/ingress-nginx-kubebuilder/internal/errors"
+	"k8s.io/klog/v2"
+	"maps"
+)
+
+// PathAnnotations are the annotations of the paths of a path override.
+type PathAnnotations struct {
+	ingressv1.PathOverride
+	Annotations *Ingress
+}
+
+// ForPath returns the annotations of path of the rule of host, those of the last override matching it or those of
+// the ingress.
+func (i IngressAnnotations) ForPath(host, path string) *Ingress {
+	for k := len(i.PathAnnotations) - 1; k >= 0; k-- {
+		if i.PathAnnotations[k].Matches(host, path) {
+			return i.PathAnnotations[k].Annotations
+		}
+	}
+
+	return i.ParsedAnnotations
+}
+
+// All returns the annotations of the ingress followed by those of its path overrides.
+func (i IngressAnnotations) All() []*Ingress {
+	list := []*Ingress{i.ParsedAnnotations}
+	for _, p := range i.PathAnnotations {
+		list = append(list, p.Annotations)
+	}
+
+	return list
+}
+
+// ExtractPaths parses the annotations of every path override of ing, set over the annotations of the ingress.
+func (e Extractor) ExtractPaths(ing *ingressv1.Ingress) ([]*PathAnnotations, error) {
+	var list []*PathAnnotations
+	for _, o := range ing.Spec.PathOverrides {
+		if err := checkPathOverride(ing, o); err != nil {
+			klog.ErrorS(err, fmt.Sprintf("invalid path override in ingress: %s, namespace: %s", ing.Name, ing.Namespace))
+			return nil, err
+		}
+
+		c := ing.DeepCopy()
+		if c.Annotations == nil {
+			c.Annotations = make(map[string]string)
+		}
+		maps.Copy(c.Annotations, o.Annotations)
+
+		anns, err := e.Extract(c)
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, &PathAnnotations{PathOverride: o, Annotations: anns})
+	}
+
+	return list, nil
+}
+
+// checkPathOverride checks that o sets location annotations of paths of the rules of ing.
+func checkPathOverride(ing *ingressv1.Ingress, o ingressv1.PathOverride) error {
+	if len(o.Paths) == 0 || len(o.Annotations) == 0 {
+		return kerr.NewInvalidIngressContent("path override", "paths and annotations are required")
+	}
+
+	if err := o.CheckAnnotations(); err != nil {
+		return kerr.NewInvalidIngressContent("path override", err.Error())
+	}
+
+	for _, path := range o.Paths {
+		if !ing.HasPath(o.Host, path) {
+			return kerr.NewInvalidIngressContent("path override", fmt.Sprintf("no rule of host %q has path %s", o.Host, path))
+		}
+	}
+
+	return nil
+}
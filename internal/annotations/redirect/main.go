@@ -6,28 +6,74 @@ import (
 	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/resolver"
 	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/errors"
 	"k8s.io/klog/v2"
+	"strconv"
+	"strings"
 )
 
 const (
-	serverRedirectHostAnnotation = "redirect-host"
-	serverRedirectPathAnnotation = "redirect-path"
+	serverRedirectHostAnnotation  = "redirect-host"
+	serverRedirectPathAnnotation  = "redirect-path"
+	permanentRedirectAnnotation   = "permanent-redirect"
+	permanentRedirectCode         = "permanent-redirect-code"
+	temporalRedirectAnnotation    = "temporal-redirect"
+	redirectPreserveURIAnnotation = "redirect-preserve-uri"
+	fromToWWWRedirectAnnotation   = "from-to-www-redirect"
+	appRootAnnotation             = "app-root"
+)
+
+const (
+	defaultPermanentCode = 301
+	temporalCode         = 302
 )
 
 var redirectAnnotation = parser.Annotation{
 	Group: "redirect",
 	Annotations: parser.AnnotationFields{
 		serverRedirectHostAnnotation: {
-			Doc: "return 301 e.g: `http://*`, required",
+			Doc: "return 301 to this url, the scheme defaults to `http://`, e.g: `https://example.com`, required by redirect-path",
 		},
 		serverRedirectPathAnnotation: {
 			Doc: "match path redirect, e.g: `/aaa`, optional",
 		},
+		permanentRedirectAnnotation: {
+			Doc: "permanently redirect every path of the ingress to this url, e.g: `https://example.com/new`, optional",
+		},
+		permanentRedirectCode: {
+			Doc: "status code of permanent-redirect, `301` or `308`, optional",
+		},
+		temporalRedirectAnnotation: {
+			Doc: "redirect every path of the ingress with a 302 to this url, e.g: `https://example.com/maintenance`, optional",
+		},
+		redirectPreserveURIAnnotation: {
+			Doc: "append the request uri, path and query, to the redirect url, which can't have a query then, optional",
+		},
+		fromToWWWRedirectAnnotation: {
+			Doc: "redirect www.host to host, or host to www.host when the rule host starts with www., optional",
+		},
+		appRootAnnotation: {
+			Doc: "redirect requests for `/` to this path, e.g: `/app`, optional",
+		},
 	},
 }
 
 type Config struct {
 	Host string `json:"host"`
 	Path string `json:"path"`
+	// URL is the target of permanent-redirect or temporal-redirect, Code its status
+	URL         string `json:"url"`
+	Code        int    `json:"code"`
+	PreserveURI bool   `json:"preserve-uri"`
+	FromToWWW   bool   `json:"from-to-www-redirect"`
+	AppRoot     string `json:"app-root"`
+}
+
+// Target returns the argument of the return directive of URL, with PreserveURI the url has no query, see check.
+func (c Config) Target() string {
+	if c.PreserveURI {
+		return strings.TrimSuffix(c.URL, "/") + "$request_uri"
+	}
+
+	return c.URL
 }
 
 type redirect struct {
@@ -55,8 +101,66 @@ func (r *redirect) Parse(ing *ingressv1.Ingress) (interface{}, error) {
 		}
 	}
 
-	if !r.check(config) {
-		return nil, errors.NewInvalidAnnotationsContentError(serverRedirectHostAnnotation, config.Host)
+	permanent, err := parser.GetStringAnnotation(permanentRedirectAnnotation, ing, redirectAnnotation.Annotations)
+	if err != nil {
+		if errors.IsValidationError(err) {
+			klog.Warningf("%s is invalid, defaulting to empty", permanentRedirectAnnotation)
+		}
+	}
+
+	temporal, err := parser.GetStringAnnotation(temporalRedirectAnnotation, ing, redirectAnnotation.Annotations)
+	if err != nil {
+		if errors.IsValidationError(err) {
+			klog.Warningf("%s is invalid, defaulting to empty", temporalRedirectAnnotation)
+		}
+	}
+
+	code, err := parser.GetStringAnnotation(permanentRedirectCode, ing, redirectAnnotation.Annotations)
+	if err != nil {
+		if errors.IsValidationError(err) {
+			klog.Warningf("%s is invalid, defaulting to %d", permanentRedirectCode, defaultPermanentCode)
+		}
+	}
+
+	config.PreserveURI, err = parser.GetBoolAnnotations(redirectPreserveURIAnnotation, ing, redirectAnnotation.Annotations)
+	if err != nil {
+		if errors.IsInvalidContentError(err) {
+			return nil, err
+		}
+		config.PreserveURI = false
+	}
+
+	config.FromToWWW, err = parser.GetBoolAnnotations(fromToWWWRedirectAnnotation, ing, redirectAnnotation.Annotations)
+	if err != nil {
+		if errors.IsInvalidContentError(err) {
+			return nil, err
+		}
+		config.FromToWWW = false
+	}
+
+	config.AppRoot, err = parser.GetStringAnnotation(appRootAnnotation, ing, redirectAnnotation.Annotations)
+	if err != nil {
+		if errors.IsValidationError(err) {
+			klog.Warningf("%s is invalid, defaulting to empty", appRootAnnotation)
+		}
+	}
+
+	switch {
+	case permanent != "" && temporal != "":
+		return nil, errors.NewInvalidAnnotationsContentError(temporalRedirectAnnotation, temporal+", "+permanentRedirectAnnotation+" is already set")
+	case permanent != "":
+		config.URL, config.Code = permanent, defaultPermanentCode
+		if code != "" {
+			if config.Code, err = strconv.Atoi(code); err != nil || (config.Code != 301 && config.Code != 308) {
+				return nil, errors.NewInvalidAnnotationsContentError(permanentRedirectCode, code)
+			}
+		}
+	case temporal != "":
+		config.URL, config.Code = temporal, temporalCode
+	}
+
+	if err := r.check(config); err != nil {
+		return nil, err
 	}
 
 	return config, nil
@@ -66,10 +170,41 @@ func (r *redirect) Validate(anns map[string]string) error {
 	return parser.CheckAnnotations(anns, redirectAnnotation.Annotations)
 }
 
-func (r *redirect) check(cfg *Config) bool {
-	if cfg.Path != "" && !parser.IsValidHost(cfg.Host) {
-		return false
+func (r *redirect) check(cfg *Config) error {
+	if cfg.Path != "" {
+		// redirect-host used to be a bare host redirected to over http
+		if !strings.Contains(cfg.Host, "://") {
+			cfg.Host = "http://" + cfg.Host
+		}
+
+		if !parser.IsValidRedirectURL(cfg.Host) {
+			return errors.NewInvalidAnnotationsContentError(serverRedirectHostAnnotation, cfg.Host)
+		}
+
+		if !parser.IsValidPath(cfg.Path) {
+			return errors.NewInvalidAnnotationsContentError(serverRedirectPathAnnotation, cfg.Path)
+		}
+	}
+
+	if cfg.URL != "" {
+		name := permanentRedirectAnnotation
+		if cfg.Code == temporalCode {
+			name = temporalRedirectAnnotation
+		}
+
+		if !parser.IsValidRedirectURL(cfg.URL) {
+			return errors.NewInvalidAnnotationsContentError(name, cfg.URL)
+		}
+
+		// the request uri is appended to the url, it would end up after its query or fragment
+		if cfg.PreserveURI && strings.ContainsAny(cfg.URL, "?#") {
+			return errors.NewInvalidAnnotationsContentError(name, cfg.URL+", "+redirectPreserveURIAnnotation+" needs a url without query")
+		}
+	}
+
+	if cfg.AppRoot != "" && !parser.IsValidPath(cfg.AppRoot) {
+		return errors.NewInvalidAnnotationsContentError(appRootAnnotation, cfg.AppRoot)
 	}
 
-	return true
+	return nil
 }
@@ -0,0 +1,37 @@
+package redirect
+
+import (
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        Config
+		wantTarget string
+		wantErr    bool
+	}{
+		{name: "permanent", cfg: Config{URL: "https://example.com/new", Code: 301}, wantTarget: "https://example.com/new"},
+		{name: "query", cfg: Config{URL: "https://example.com/new?a=b", Code: 302}, wantTarget: "https://example.com/new?a=b"},
+		{name: "preserve uri", cfg: Config{URL: "https://example.com/", Code: 301, PreserveURI: true}, wantTarget: "https://example.com$request_uri"},
+		{name: "preserve uri with path", cfg: Config{URL: "https://example.com/new", Code: 302, PreserveURI: true}, wantTarget: "https://example.com/new$request_uri"},
+		{name: "preserve uri with query", cfg: Config{URL: "https://example.com/new?a=b", Code: 301, PreserveURI: true}, wantErr: true},
+		{name: "preserve uri with empty query", cfg: Config{URL: "https://example.com/new?", Code: 302, PreserveURI: true}, wantErr: true},
+		{name: "invalid url", cfg: Config{URL: "https://example.com/$host", Code: 301}, wantErr: true},
+		{name: "bare redirect host", cfg: Config{Host: "example.com", Path: "/old"}},
+		{name: "invalid redirect path", cfg: Config{Host: "https://example.com", Path: "old"}, wantErr: true},
+		{name: "invalid app root", cfg: Config{AppRoot: "/app;"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			if err := (&redirect{}).check(&cfg); (err != nil) != tt.wantErr {
+				t.Fatalf("check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && cfg.Target() != tt.wantTarget {
+				t.Errorf("Target() = %q, want %q", cfg.Target(), tt.wantTarget)
+			}
+		})
+	}
+}
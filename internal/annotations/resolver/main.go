@@ -8,8 +8,10 @@ import (
 
 type Resolver interface {
 	GetSecret(client.ObjectKey) (*corev1.Secret, error)
+	GetConfigMap(string) (*corev1.ConfigMap, error)
 	GetDefaultService() (*corev1.Service, error)
 	GetService(string) (*corev1.Service, error)
+	GetEndpoints(string, int32) ([]string, error)
 	GetHostName() []string
 	GetSvcPort(interface{}) *int32
 	GetTlsData(client.ObjectKey) (map[string][]byte, error)
@@ -0,0 +1,238 @@
+package snippet
+
+import (
+	"fmt"
+	ingressv1 "github.com/ingoxx/ingress-nginx-kubebuilder/api/v1"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/parser"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/resolver"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/config"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/errors"
+	"k8s.io/klog/v2"
+	"path"
+	"slices"
+	"strings"
+)
+
+const (
+	serverSnippet        = "server-snippet"
+	configurationSnippet = "configuration-snippet"
+)
+
+var snippetAnnotations = parser.Annotation{
+	Group: "snippet",
+	Annotations: parser.AnnotationFields{
+		serverSnippet: {
+			Doc: "nginx directives added to the servers of the ingress, needs --allow-snippet-annotations, optional",
+		},
+		configurationSnippet: {
+			Doc: "nginx directives added to the locations of the ingress, needs --allow-snippet-annotations, optional",
+		},
+	},
+}
+
+// blockedDirectives can't be used in a snippet whatever the policy, they run code or open files of the controller,
+// nginx opens the files of the logs, temp paths, certificates and password files as the master process.
+var blockedDirectives = []string{
+	"include", "root", "alias", "load_module", "*lua*", "perl*", "js_*",
+	"access_log", "error_log", "*_temp_path", "ssl_certificate*", "ssl_password_file", "ssl_trusted_certificate",
+	"ssl_client_certificate", "ssl_crl", "ssl_dhparam", "ssl_stapling_file", "ssl_session_ticket_key",
+	"proxy_ssl_certificate*", "proxy_ssl_password_file", "proxy_ssl_trusted_certificate", "proxy_ssl_crl",
+	"auth_basic_user_file", "*_store", "*_cache_path",
+}
+
+// Config holds the directives of the snippets, checked against the directive policy.
+type Config struct {
+	ServerSnippet        string `json:"server-snippet,omitempty"`
+	ConfigurationSnippet string `json:"configuration-snippet,omitempty"`
+}
+
+type snippet struct {
+	r resolver.Resolver
+}
+
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return &snippet{r: r}
+}
+
+func (s *snippet) Parse(ing *ingressv1.Ingress) (interface{}, error) {
+	cfg := &Config{}
+
+	var err error
+	for _, a := range []struct {
+		name string
+		val  *string
+	}{{serverSnippet, &cfg.ServerSnippet}, {configurationSnippet, &cfg.ConfigurationSnippet}} {
+		*a.val, err = parser.GetStringAnnotation(a.name, ing, snippetAnnotations.Annotations)
+		if err != nil {
+			if errors.IsValidationError(err) {
+				klog.Warningf("%s is invalid, defaulting to empty", a.name)
+			}
+		}
+
+		if *a.val = strings.TrimSpace(*a.val); *a.val == "" {
+			continue
+		}
+
+		if !Allowed(ing.Namespace) {
+			err = errors.NewRiskyAnnotationsError(fmt.Sprintf("%s is not allowed in namespace %s, see --allow-snippet-annotations and --snippet-namespaces", a.name, ing.Namespace))
+			klog.ErrorS(err, fmt.Sprintf("ingress: %s, namespace: %s", ing.Name, ing.Namespace))
+			return nil, err
+		}
+
+		if err = CheckDirectives(*a.val); err != nil {
+			return nil, errors.NewRiskyAnnotationsError(fmt.Sprintf("%s: %v", a.name, err))
+		}
+	}
+
+	return cfg, nil
+}
+
+// Validate refuses the snippets when they are disabled, the ingress is then rejected as risky.
+func (s *snippet) Validate(anns map[string]string) error {
+	if err := parser.CheckAnnotations(anns, snippetAnnotations.Annotations); err != nil {
+		return err
+	}
+
+	if config.AllowSnippetAnnotations {
+		return nil
+	}
+
+	for name := range snippetAnnotations.Annotations {
+		if _, ok := anns[parser.GetAnnotationWithPrefix(name)]; ok {
+			return fmt.Errorf("%s is disabled, see --allow-snippet-annotations", name)
+		}
+	}
+
+	return nil
+}
+
+// Allowed reports whether the snippet annotations are enabled for the ingresses of namespace.
+func Allowed(namespace string) bool {
+	if !config.AllowSnippetAnnotations {
+		return false
+	}
+
+	namespaces := splitList(config.SnippetNamespaces)
+	return len(namespaces) == 0 || slices.Contains(namespaces, namespace)
+}
+
+// CheckDirectives parses the directives of a snippet, blocks included, and checks each of them against the blocked
+// directives, --snippet-denied-directives and, when it is set, --snippet-allowed-directives.
+func CheckDirectives(s string) error {
+	// the rendered servers are parsed again as a template
+	if strings.Contains(s, "{{") || strings.Contains(s, "}}") {
+		return fmt.Errorf("{{ and }} are not allowed")
+	}
+
+	allowed := splitList(config.SnippetAllowedDirectives)
+	denied := append(splitList(config.SnippetDeniedDirectives), blockedDirectives...)
+
+	check := func(directive string) error {
+		// nginx strips the quotes and escapes of a directive name
+		directive = strings.NewReplacer(`"`, "", `'`, "", `\`, "").Replace(directive)
+		if matchAny(denied, directive) {
+			return fmt.Errorf("directive %s is not allowed", directive)
+		}
+		if len(allowed) > 0 && !matchAny(allowed, directive) {
+			return fmt.Errorf("directive %s is not in the allowed directives", directive)
+		}
+
+		return nil
+	}
+
+	var tokens []string
+	var token strings.Builder
+	var quote rune
+	var escaped, comment bool
+	var depth int
+
+	flush := func() {
+		if token.Len() > 0 {
+			tokens = append(tokens, token.String())
+			token.Reset()
+		}
+	}
+
+	for _, c := range s {
+		switch {
+		case comment:
+			comment = c != '\n'
+		case escaped:
+			token.WriteRune(c)
+			escaped = false
+		case c == '\\':
+			token.WriteRune(c)
+			escaped = true
+		case quote != 0:
+			token.WriteRune(c)
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			token.WriteRune(c)
+			quote = c
+		case c == '#' && token.Len() == 0:
+			comment = true
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			flush()
+		case c == '{' && strings.HasSuffix(token.String(), "$"), c == '}' && token.Len() > 0:
+			// like nginx, ${var} is a variable and a } inside a token is part of it
+			token.WriteRune(c)
+		case c == ';' || c == '{':
+			flush()
+			if len(tokens) == 0 {
+				return fmt.Errorf("unexpected %q", c)
+			}
+			if err := check(tokens[0]); err != nil {
+				return err
+			}
+			if c == '{' {
+				depth++
+			}
+			tokens = nil
+		case c == '}':
+			flush()
+			if len(tokens) > 0 {
+				return fmt.Errorf("directive %s is not terminated by \";\"", tokens[0])
+			}
+			if depth--; depth < 0 {
+				return fmt.Errorf("unexpected \"}\"")
+			}
+		default:
+			token.WriteRune(c)
+		}
+	}
+
+	flush()
+	switch {
+	case quote != 0:
+		return fmt.Errorf("unterminated quoted string")
+	case len(tokens) > 0:
+		return fmt.Errorf("directive %s is not terminated by \";\"", tokens[0])
+	case depth > 0:
+		return fmt.Errorf("unexpected end of snippet, expecting \"}\"")
+	}
+
+	return nil
+}
+
+func matchAny(patterns []string, directive string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, directive); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,61 @@
+package snippet
+
+import (
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/config"
+	"testing"
+)
+
+func TestCheckDirectives(t *testing.T) {
+	tests := []struct {
+		name    string
+		snippet string
+		allowed string
+		denied  string
+		wantErr bool
+	}{
+		{name: "directives", snippet: "add_header X-Foo bar;\nproxy_buffering off;"},
+		{name: "quoted name", snippet: `"inc"lude /etc/passwd;`, wantErr: true},
+		{name: "single quoted name", snippet: `'include' /etc/passwd;`, wantErr: true},
+		{name: "escaped name", snippet: `in\clude /etc/passwd;`, wantErr: true},
+		{name: "quoted argument", snippet: `add_header X-Foo "a; include b {";`},
+		{name: "nested blocks", snippet: "location /a { location /b { return 200; } }"},
+		{name: "blocked directive in nested block", snippet: "location /a { if ($x) { root /; } }", wantErr: true},
+		{name: "lua glob", snippet: "content_by_lua_block { ngx.say(1) }", wantErr: true},
+		{name: "lua glob in block", snippet: "location / { access_by_lua 'x'; }", wantErr: true},
+		{name: "comment", snippet: "# include /etc/passwd;\nreturn 200;"},
+		{name: "comment after directive", snippet: "return 200; # include {"},
+		{name: "hash inside token", snippet: "return 200 a#b;"},
+		{name: "unbalanced open brace", snippet: "location / { return 200;", wantErr: true},
+		{name: "unbalanced close brace", snippet: "return 200; }", wantErr: true},
+		{name: "unterminated directive", snippet: "return 200", wantErr: true},
+		{name: "unterminated quote", snippet: `add_header X-Foo "bar;`, wantErr: true},
+		{name: "block without name", snippet: "{ return 200; }", wantErr: true},
+		{name: "template action", snippet: `add_header X-Foo "{{ .Server }}";`, wantErr: true},
+		{name: "template action end", snippet: "return 200 }};", wantErr: true},
+		{name: "braced variable", snippet: "add_header X-Foo ${host}bar;"},
+		{name: "braced variable in block", snippet: "location / { return 301 https://${host}$request_uri; }"},
+		{name: "denied directive", snippet: "proxy_pass http://a;", denied: "proxy_*", wantErr: true},
+		{name: "access log", snippet: "access_log /etc/nginx/nginx.conf;", wantErr: true},
+		{name: "access log off", snippet: "access_log off;", wantErr: true},
+		{name: "error log", snippet: "error_log /etc/nginx/nginx.conf debug;", wantErr: true},
+		{name: "client body temp path", snippet: "client_body_temp_path /etc/nginx;", wantErr: true},
+		{name: "proxy temp path", snippet: "location / { proxy_temp_path /etc; }", wantErr: true},
+		{name: "ssl certificate", snippet: "ssl_certificate /etc/shadow;", wantErr: true},
+		{name: "ssl certificate key", snippet: "ssl_certificate_key /etc/shadow;", wantErr: true},
+		{name: "ssl password file", snippet: "ssl_password_file /etc/shadow;", wantErr: true},
+		{name: "auth basic user file", snippet: "auth_basic_user_file /etc/shadow;", wantErr: true},
+		{name: "proxy store", snippet: "proxy_store /etc/nginx/nginx.conf;", wantErr: true},
+		{name: "blocked even when allowed", snippet: "access_log /tmp/x;", allowed: "access_log", wantErr: true},
+		{name: "allowed directive", snippet: "add_header X-Foo bar;", allowed: "add_header"},
+		{name: "not allowed directive", snippet: "return 200;", allowed: "add_header", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.SnippetAllowedDirectives, config.SnippetDeniedDirectives = tt.allowed, tt.denied
+			if err := CheckDirectives(tt.snippet); (err != nil) != tt.wantErr {
+				t.Errorf("CheckDirectives(%q) error = %v, wantErr %v", tt.snippet, err, tt.wantErr)
+			}
+		})
+	}
+}
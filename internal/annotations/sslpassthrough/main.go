@@ -0,0 +1,52 @@
+package sslpassthrough
+
+import (
+	ingressv1 "github.com/ingoxx/ingress-nginx-kubebuilder/api/v1"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/parser"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/resolver"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/errors"
+	"k8s.io/klog/v2"
+)
+
+const (
+	sslPassthrough = "ssl-passthrough"
+)
+
+type Passthrough struct {
+	r resolver.Resolver
+}
+
+type Config struct {
+	SSLPassthrough bool `json:"ssl-passthrough"`
+}
+
+var passthroughAnnotations = parser.Annotation{
+	Group: "sslPassthrough",
+	Annotations: parser.AnnotationFields{
+		sslPassthrough: {
+			Doc: "route the TLS connections of the ingress hosts by SNI to their backend, which terminates TLS itself, optional",
+		},
+	},
+}
+
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return &Passthrough{}
+}
+
+func (p *Passthrough) Parse(ing *ingressv1.Ingress) (interface{}, error) {
+	var err error
+	config := &Config{}
+	config.SSLPassthrough, err = parser.GetBoolAnnotations(sslPassthrough, ing, passthroughAnnotations.Annotations)
+	if err != nil {
+		if errors.IsValidationError(err) {
+			klog.Warningf("%s is invalid, defaulting to false", sslPassthrough)
+		}
+		config.SSLPassthrough = false
+	}
+
+	return config, nil
+}
+
+func (p *Passthrough) Validate(anns map[string]string) error {
+	return parser.CheckAnnotations(anns, passthroughAnnotations.Annotations)
+}
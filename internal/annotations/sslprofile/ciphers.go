@@ -0,0 +1,176 @@
+package sslprofile
+
+import (
+	"fmt"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/pkg/utils/cmd"
+	"k8s.io/klog/v2"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// protocols are the ssl_protocols of OpenSSL 3.0, the version the nginx image is built with, OpenSSL 3 no longer
+// implements SSLv2 and SSLv3. Load replaces them with those of the OpenSSL nginx is actually built with.
+var protocols = []string{"TLSv1", "TLSv1.1", "TLSv1.2", "TLSv1.3"}
+
+// cipherNames are the TLSv1.2 and older cipher suites of OpenSSL 3.0, TLSv1.3 suites are not configured through
+// ssl_ciphers. Load replaces them with the output of `openssl ciphers` when the binary is installed.
+var cipherNames = []string{
+	"ECDHE-ECDSA-AES128-GCM-SHA256", "ECDHE-RSA-AES128-GCM-SHA256",
+	"ECDHE-ECDSA-AES256-GCM-SHA384", "ECDHE-RSA-AES256-GCM-SHA384",
+	"ECDHE-ECDSA-CHACHA20-POLY1305", "ECDHE-RSA-CHACHA20-POLY1305",
+	"ECDHE-ECDSA-AES128-CCM", "ECDHE-ECDSA-AES256-CCM", "ECDHE-ECDSA-AES128-CCM8", "ECDHE-ECDSA-AES256-CCM8",
+	"ECDHE-ECDSA-AES128-SHA256", "ECDHE-RSA-AES128-SHA256", "ECDHE-ECDSA-AES256-SHA384", "ECDHE-RSA-AES256-SHA384",
+	"ECDHE-ECDSA-AES128-SHA", "ECDHE-RSA-AES128-SHA", "ECDHE-ECDSA-AES256-SHA", "ECDHE-RSA-AES256-SHA",
+	"ECDHE-ECDSA-ARIA128-GCM-SHA256", "ECDHE-ECDSA-ARIA256-GCM-SHA384",
+	"ECDHE-ARIA128-GCM-SHA256", "ECDHE-ARIA256-GCM-SHA384",
+	"ECDHE-ECDSA-CAMELLIA128-SHA256", "ECDHE-ECDSA-CAMELLIA256-SHA384",
+	"ECDHE-RSA-CAMELLIA128-SHA256", "ECDHE-RSA-CAMELLIA256-SHA384",
+	"DHE-RSA-AES128-GCM-SHA256", "DHE-RSA-AES256-GCM-SHA384", "DHE-RSA-CHACHA20-POLY1305",
+	"DHE-RSA-AES128-CCM", "DHE-RSA-AES256-CCM", "DHE-RSA-AES128-CCM8", "DHE-RSA-AES256-CCM8",
+	"DHE-RSA-AES128-SHA256", "DHE-RSA-AES256-SHA256", "DHE-RSA-AES128-SHA", "DHE-RSA-AES256-SHA",
+	"DHE-RSA-ARIA128-GCM-SHA256", "DHE-RSA-ARIA256-GCM-SHA384",
+	"DHE-RSA-CAMELLIA128-SHA256", "DHE-RSA-CAMELLIA256-SHA256", "DHE-RSA-CAMELLIA128-SHA", "DHE-RSA-CAMELLIA256-SHA",
+	"AES128-GCM-SHA256", "AES256-GCM-SHA384", "AES128-CCM", "AES256-CCM", "AES128-CCM8", "AES256-CCM8",
+	"AES128-SHA256", "AES256-SHA256", "AES128-SHA", "AES256-SHA",
+	"ARIA128-GCM-SHA256", "ARIA256-GCM-SHA384",
+	"CAMELLIA128-SHA256", "CAMELLIA256-SHA256", "CAMELLIA128-SHA", "CAMELLIA256-SHA",
+	"DES-CBC3-SHA", "ECDHE-RSA-DES-CBC3-SHA", "EDH-RSA-DES-CBC3-SHA",
+}
+
+// cipherAliases are the keywords of the OpenSSL cipher list format, they can be combined with "+".
+var cipherAliases = []string{
+	"ALL", "COMPLEMENTOFALL", "COMPLEMENTOFDEFAULT", "DEFAULT", "HIGH", "MEDIUM", "LOW",
+	"eNULL", "NULL", "aNULL", "kRSA", "aRSA", "RSA", "kDHE", "kEDH", "DH", "DHE", "EDH", "ADH",
+	"kEECDH", "kECDHE", "ECDH", "ECDHE", "EECDH", "AECDH", "aDSS", "DSS", "aECDSA", "ECDSA",
+	"TLSv1.2", "TLSv1.0", "TLSv1", "SSLv3",
+	"AES", "AES128", "AES256", "AESGCM", "AESCCM", "AESCCM8", "ARIA", "ARIA128", "ARIA256", "ARIAGCM",
+	"CAMELLIA", "CAMELLIA128", "CAMELLIA256", "CHACHA20", "3DES", "DES", "RC4", "RC2", "IDEA", "SEED",
+	"MD5", "SHA1", "SHA", "SHA256", "SHA384",
+	"PSK", "kPSK", "aPSK", "kECDHEPSK", "kDHEPSK", "kRSAPSK", "SRP", "kSRP", "aSRP",
+	"EXP", "EXPORT", "SUITEB128", "SUITEB128ONLY", "SUITEB192",
+}
+
+var (
+	knownCiphers = known(cipherNames)
+
+	cipherCommand = regexp.MustCompile(`^@(STRENGTH|SECLEVEL=[0-5])$`)
+
+	// opensslVersion finds the OpenSSL version in the output of nginx -V.
+	opensslVersion = regexp.MustCompile(`built with OpenSSL (\d+)\.(\d+)\.(\d+)`)
+)
+
+// Load derives the supported protocols from the OpenSSL version nginx is built with and the supported ciphers from
+// `openssl ciphers`, the pinned OpenSSL 3.0 lists are kept for whatever can't be found out.
+func Load(nginxBin string) {
+	out, err := cmd.NewCommand(nginxBin, false, []string{"-V"}).CombinedOutput()
+	if err != nil {
+		klog.Warningf("fail to run %s -V, keeping the OpenSSL 3.0 protocols: %v", nginxBin, err)
+	} else if m := opensslVersion.FindSubmatch(out); m != nil {
+		major, _ := strconv.Atoi(string(m[1]))
+		minor, _ := strconv.Atoi(string(m[2]))
+		patch, _ := strconv.Atoi(string(m[3]))
+		protocols = protocolsOf(major, minor, patch)
+	}
+
+	out, err = cmd.NewCommand("openssl", false, []string{"ciphers", "ALL:COMPLEMENTOFALL"}).Output()
+	if err != nil {
+		klog.Warningf("fail to run openssl ciphers, keeping the OpenSSL 3.0 ciphers: %v", err)
+		return
+	}
+
+	var names []string
+	for _, name := range strings.Split(strings.TrimSpace(string(out)), ":") {
+		if name != "" && !strings.HasPrefix(name, "TLS_") {
+			names = append(names, name)
+		}
+	}
+	if len(names) > 0 {
+		cipherNames, knownCiphers = names, known(names)
+	}
+}
+
+// protocolsOf returns the ssl_protocols of an OpenSSL version, TLSv1.3 came with 1.1.1.
+func protocolsOf(major, minor, patch int) []string {
+	list := []string{"TLSv1", "TLSv1.1", "TLSv1.2"}
+	if major > 1 || (major == 1 && (minor > 1 || (minor == 1 && patch >= 1))) {
+		list = append(list, "TLSv1.3")
+	}
+
+	return list
+}
+
+// known returns the set of the cipher names and the keywords of the cipher list format.
+func known(names []string) map[string]bool {
+	m := make(map[string]bool)
+	for _, v := range names {
+		m[v] = true
+	}
+	for _, v := range cipherAliases {
+		m[v] = true
+	}
+
+	return m
+}
+
+// NormalizeProtocols checks a space or comma separated protocol list and returns it in the ssl_protocols form.
+func NormalizeProtocols(list string) (string, error) {
+	fields := strings.FieldsFunc(list, func(r rune) bool { return r == ' ' || r == ',' })
+	if len(fields) == 0 {
+		return "", fmt.Errorf("no protocol given")
+	}
+
+	want := make(map[string]bool)
+	for _, f := range fields {
+		if !contains(protocols, f) {
+			return "", fmt.Errorf("protocol %s is not supported, supported: %s", f, strings.Join(protocols, " "))
+		}
+		want[f] = true
+	}
+
+	var out []string
+	for _, p := range protocols {
+		if want[p] {
+			out = append(out, p)
+		}
+	}
+
+	return strings.Join(out, " "), nil
+}
+
+// ValidateCiphers checks every element of an OpenSSL cipher list against the ciphers and keywords the bundled
+// OpenSSL knows, which also keeps the value from injecting anything into the configuration.
+func ValidateCiphers(list string) error {
+	if list == "" {
+		return fmt.Errorf("no cipher given")
+	}
+
+	for _, elem := range strings.Split(list, ":") {
+		if cipherCommand.MatchString(elem) {
+			continue
+		}
+
+		name := strings.TrimLeft(elem, "!-+")
+		if name == "" {
+			return fmt.Errorf("empty element in cipher list %s", list)
+		}
+
+		for _, part := range strings.Split(name, "+") {
+			if !knownCiphers[part] {
+				return fmt.Errorf("cipher %s is not supported", part)
+			}
+		}
+	}
+
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,95 @@
+package sslprofile
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNormalizeProtocols(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "TLSv1.2 TLSv1.3", want: "TLSv1.2 TLSv1.3"},
+		{in: "TLSv1.3,TLSv1.2", want: "TLSv1.2 TLSv1.3"},
+		{in: " TLSv1.2 ,, TLSv1.2 ", want: "TLSv1.2"},
+		{in: "TLSv1 TLSv1.1", want: "TLSv1 TLSv1.1"},
+		{in: "", wantErr: true},
+		{in: " , ", wantErr: true},
+		{in: "SSLv3", wantErr: true},
+		{in: "tlsv1.2", wantErr: true},
+		{in: "TLSv1.2;", wantErr: true},
+		{in: "TLSv1.2 ssl_ciphers ALL", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := NormalizeProtocols(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeProtocols(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeProtocols(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateCiphers(t *testing.T) {
+	tests := []struct {
+		in      string
+		wantErr bool
+	}{
+		{in: "ECDHE-RSA-AES128-GCM-SHA256:ECDHE-RSA-AES256-GCM-SHA384"},
+		{in: "HIGH:!aNULL:!MD5"},
+		{in: "ECDHE+AESGCM:-kRSA:+SHA1"},
+		{in: "DEFAULT:@STRENGTH"},
+		{in: "DEFAULT:@SECLEVEL=2"},
+		{in: "DEFAULT:@SECLEVEL=9", wantErr: true},
+		{in: "", wantErr: true},
+		{in: "HIGH::MEDIUM", wantErr: true},
+		{in: "HIGH:!", wantErr: true},
+		{in: "ECDHE+", wantErr: true},
+		{in: "UNKNOWN-CIPHER", wantErr: true},
+		{in: "TLS_AES_128_GCM_SHA256", wantErr: true},
+		{in: "HIGH;", wantErr: true},
+		{in: "HIGH; include /etc/passwd", wantErr: true},
+		{in: "HIGH MEDIUM", wantErr: true},
+		{in: "HIGH}", wantErr: true},
+		{in: "HIGH{", wantErr: true},
+		{in: "$ssl_ciphers", wantErr: true},
+		{in: "HIGH\nMEDIUM", wantErr: true},
+		{in: `"HIGH"`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if err := ValidateCiphers(tt.in); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCiphers(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProtocolsOf(t *testing.T) {
+	tests := []struct {
+		name                string
+		major, minor, patch int
+		want                []string
+	}{
+		{name: "1.0.2", major: 1, minor: 0, patch: 2, want: []string{"TLSv1", "TLSv1.1", "TLSv1.2"}},
+		{name: "1.1.0", major: 1, minor: 1, patch: 0, want: []string{"TLSv1", "TLSv1.1", "TLSv1.2"}},
+		{name: "1.1.1", major: 1, minor: 1, patch: 1, want: []string{"TLSv1", "TLSv1.1", "TLSv1.2", "TLSv1.3"}},
+		{name: "1.2.0", major: 1, minor: 2, patch: 0, want: []string{"TLSv1", "TLSv1.1", "TLSv1.2", "TLSv1.3"}},
+		{name: "3.0.0", major: 3, minor: 0, patch: 0, want: []string{"TLSv1", "TLSv1.1", "TLSv1.2", "TLSv1.3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := protocolsOf(tt.major, tt.minor, tt.patch); !slices.Equal(got, tt.want) {
+				t.Errorf("protocolsOf(%d, %d, %d) = %v, want %v", tt.major, tt.minor, tt.patch, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package sslprofile
+
+import (
+	"fmt"
+	ingressv1 "github.com/ingoxx/ingress-nginx-kubebuilder/api/v1"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/parser"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/resolver"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/config"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/errors"
+	"k8s.io/klog/v2"
+	"strings"
+)
+
+const (
+	sslProfile            = "ssl-profile"
+	sslProtocols          = "ssl-protocols"
+	sslCiphers            = "ssl-ciphers"
+	hstsMaxAge            = "hsts-max-age"
+	hstsIncludeSubdomains = "hsts-include-subdomains"
+	hstsPreload           = "hsts-preload"
+)
+
+// hstsPreloadMinAge is the shortest max-age the HSTS preload list accepts.
+const hstsPreloadMinAge = 31536000
+
+var profileAnnotations = parser.Annotation{
+	Group: "sslProfile",
+	Annotations: parser.AnnotationFields{
+		sslProfile: {
+			Doc: "tls profile of the https server, `modern`, `intermediate`, `legacy` or `custom`, optional",
+		},
+		sslProtocols: {
+			Doc: "ssl_protocols overriding the profile, e.g: `TLSv1.2 TLSv1.3`, required by the custom profile",
+		},
+		sslCiphers: {
+			Doc: "OpenSSL cipher list overriding the profile, e.g: `ECDHE-RSA-AES128-GCM-SHA256:ECDHE-RSA-AES256-GCM-SHA384`, required by the custom profile",
+		},
+		hstsMaxAge: {
+			Doc: "max-age of the Strict-Transport-Security header in seconds, `0` drops the header, optional",
+		},
+		hstsIncludeSubdomains: {
+			Doc: "add includeSubDomains to the Strict-Transport-Security header, optional",
+		},
+		hstsPreload: {
+			Doc: "add preload to the Strict-Transport-Security header, needs hsts-include-subdomains and a max-age of a year, optional",
+		},
+	},
+}
+
+type Config struct {
+	Profile               string `json:"ssl-profile"`
+	Protocols             string `json:"ssl-protocols"`
+	Ciphers               string `json:"ssl-ciphers"`
+	PreferServerCiphers   bool   `json:"prefer-server-ciphers"`
+	HSTSMaxAge            int    `json:"hsts-max-age"`
+	HSTSIncludeSubdomains bool   `json:"hsts-include-subdomains"`
+	HSTSPreload           bool   `json:"hsts-preload"`
+	// HSTS is the value of the Strict-Transport-Security header, empty when it is not sent
+	HSTS string `json:"hsts"`
+}
+
+type profile struct {
+	r resolver.Resolver
+}
+
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return &profile{}
+}
+
+// Parse starts from the global settings, every annotation that is set overrides its part of them.
+func (p *profile) Parse(ing *ingressv1.Ingress) (interface{}, error) {
+	cfg := &Config{
+		Profile:               config.SSLProfile,
+		HSTSMaxAge:            config.HSTSMaxAge,
+		HSTSIncludeSubdomains: config.HSTSIncludeSubdomains,
+		HSTSPreload:           config.HSTSPreload,
+	}
+
+	name, err := parser.GetStringAnnotation(sslProfile, ing, profileAnnotations.Annotations)
+	if err != nil {
+		if errors.IsValidationError(err) {
+			klog.Warningf("%s is invalid, defaulting to %s", sslProfile, cfg.Profile)
+		}
+	}
+
+	var tp config.TLSProfile
+	if name != "" {
+		var ok bool
+		if tp, ok = config.TLSProfiles[name]; !ok {
+			return nil, errors.NewInvalidAnnotationsContentError(sslProfile, name)
+		}
+		cfg.Profile = name
+	} else {
+		tp = config.DefaultTLSProfile()
+	}
+
+	cfg.Protocols, cfg.Ciphers, cfg.PreferServerCiphers = tp.Protocols, tp.Ciphers, tp.PreferServerCiphers
+
+	protocols, err := parser.GetStringAnnotation(sslProtocols, ing, profileAnnotations.Annotations)
+	if err != nil {
+		if errors.IsValidationError(err) {
+			klog.Warningf("%s is invalid, defaulting to the %s profile", sslProtocols, cfg.Profile)
+		}
+	}
+	if protocols != "" {
+		if cfg.Protocols, err = NormalizeProtocols(protocols); err != nil {
+			return nil, errors.NewInvalidAnnotationsContentError(sslProtocols, fmt.Sprintf("%s, %v", protocols, err))
+		}
+	}
+
+	ciphers, err := parser.GetStringAnnotation(sslCiphers, ing, profileAnnotations.Annotations)
+	if err != nil {
+		if errors.IsValidationError(err) {
+			klog.Warningf("%s is invalid, defaulting to the %s profile", sslCiphers, cfg.Profile)
+		}
+	}
+	if ciphers != "" {
+		if err = ValidateCiphers(ciphers); err != nil {
+			return nil, errors.NewInvalidAnnotationsContentError(sslCiphers, fmt.Sprintf("%s, %v", ciphers, err))
+		}
+		cfg.Ciphers = ciphers
+	}
+
+	if cfg.Profile == config.SSLProfileCustom && (cfg.Protocols == "" || cfg.Ciphers == "") {
+		return nil, errors.NewInvalidAnnotationsContentError(sslProfile, "custom requires "+sslProtocols+" and "+sslCiphers)
+	}
+
+	maxAge, err := parser.GetIntAnnotation(hstsMaxAge, ing, profileAnnotations.Annotations)
+	if err == nil {
+		cfg.HSTSMaxAge = maxAge
+	} else if errors.IsInvalidContentError(err) {
+		return nil, err
+	}
+
+	sub, err := parser.GetBoolAnnotations(hstsIncludeSubdomains, ing, profileAnnotations.Annotations)
+	if err == nil {
+		cfg.HSTSIncludeSubdomains = sub
+	} else if errors.IsInvalidContentError(err) {
+		return nil, err
+	}
+
+	preload, err := parser.GetBoolAnnotations(hstsPreload, ing, profileAnnotations.Annotations)
+	if err == nil {
+		cfg.HSTSPreload = preload
+	} else if errors.IsInvalidContentError(err) {
+		return nil, err
+	}
+
+	if err := checkHSTS(cfg.HSTSMaxAge, cfg.HSTSIncludeSubdomains, cfg.HSTSPreload); err != nil {
+		return nil, errors.NewInvalidAnnotationsContentError(hstsPreload, err.Error())
+	}
+
+	cfg.HSTS = HSTSHeader(cfg.HSTSMaxAge, cfg.HSTSIncludeSubdomains, cfg.HSTSPreload)
+
+	return cfg, nil
+}
+
+func (p *profile) Validate(anns map[string]string) error {
+	return parser.CheckAnnotations(anns, profileAnnotations.Annotations)
+}
+
+// Default returns the settings of the servers without tls annotations.
+func Default() Config {
+	p := config.DefaultTLSProfile()
+
+	return Config{
+		Profile:               config.SSLProfile,
+		Protocols:             p.Protocols,
+		Ciphers:               p.Ciphers,
+		PreferServerCiphers:   p.PreferServerCiphers,
+		HSTSMaxAge:            config.HSTSMaxAge,
+		HSTSIncludeSubdomains: config.HSTSIncludeSubdomains,
+		HSTSPreload:           config.HSTSPreload,
+		HSTS:                  HSTSHeader(config.HSTSMaxAge, config.HSTSIncludeSubdomains, config.HSTSPreload),
+	}
+}
+
+// HSTSHeader returns the Strict-Transport-Security value, empty when maxAge disables it.
+func HSTSHeader(maxAge int, includeSubdomains, preload bool) string {
+	if maxAge <= 0 {
+		return ""
+	}
+
+	v := []string{fmt.Sprintf("max-age=%d", maxAge)}
+	if includeSubdomains {
+		v = append(v, "includeSubDomains")
+	}
+	if preload {
+		v = append(v, "preload")
+	}
+
+	return strings.Join(v, "; ")
+}
+
+func checkHSTS(maxAge int, includeSubdomains, preload bool) error {
+	if maxAge < 0 {
+		return fmt.Errorf("negative max-age %d", maxAge)
+	}
+
+	if preload && (maxAge < hstsPreloadMinAge || !includeSubdomains) {
+		return fmt.Errorf("preload requires includeSubDomains and a max-age of at least %d", hstsPreloadMinAge)
+	}
+
+	return nil
+}
+
+// ValidateDefaults checks the global tls settings given on the command line.
+func ValidateDefaults() error {
+	if _, ok := config.TLSProfiles[config.SSLProfile]; !ok {
+		return fmt.Errorf("unknown ssl profile %s", config.SSLProfile)
+	}
+
+	if config.SSLProtocols != "" {
+		protocols, err := NormalizeProtocols(config.SSLProtocols)
+		if err != nil {
+			return err
+		}
+		config.SSLProtocols = protocols
+	}
+
+	if config.SSLCiphers != "" {
+		if err := ValidateCiphers(config.SSLCiphers); err != nil {
+			return err
+		}
+	}
+
+	if p := config.DefaultTLSProfile(); config.SSLProfile == config.SSLProfileCustom && (p.Protocols == "" || p.Ciphers == "") {
+		return fmt.Errorf("the %s ssl profile requires ssl protocols and ciphers", config.SSLProfile)
+	}
+
+	return checkHSTS(config.HSTSMaxAge, config.HSTSIncludeSubdomains, config.HSTSPreload)
+}
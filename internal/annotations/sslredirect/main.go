@@ -0,0 +1,102 @@
+package sslredirect
+
+import (
+	ingressv1 "github.com/ingoxx/ingress-nginx-kubebuilder/api/v1"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/parser"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/resolver"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/config"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/errors"
+)
+
+const (
+	sslRedirect      = "ssl-redirect"
+	forceSSLRedirect = "force-ssl-redirect"
+	disableHTTP      = "disable-http"
+	disableHTTPS     = "disable-https"
+)
+
+var redirectAnnotations = parser.Annotation{
+	Group: "sslRedirect",
+	Annotations: parser.AnnotationFields{
+		sslRedirect: {
+			Doc: "redirect plain http requests of the hosts with a certificate to https with a 308, optional",
+		},
+		forceSSLRedirect: {
+			Doc: "redirect plain http requests to https with a 308 even when the host has no certificate of its own, optional",
+		},
+		disableHTTP: {
+			Doc: "refuse plain http requests of the hosts, acme http01 challenges are still answered, optional",
+		},
+		disableHTTPS: {
+			Doc: "don't serve the hosts on the https port, optional",
+		},
+	},
+}
+
+// Config decides how the hosts of an ingress are served on the http and https ports, the acme http01 challenge
+// paths are never redirected nor refused so cert-manager can always solve them.
+type Config struct {
+	SSLRedirect      bool `json:"ssl-redirect"`
+	ForceSSLRedirect bool `json:"force-ssl-redirect"`
+	DisableHTTP      bool `json:"disable-http"`
+	DisableHTTPS     bool `json:"disable-https"`
+}
+
+type redirect struct {
+	r resolver.Resolver
+}
+
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return &redirect{}
+}
+
+func (s *redirect) Parse(ing *ingressv1.Ingress) (interface{}, error) {
+	cfg := &Config{SSLRedirect: config.SSLRedirect}
+
+	fields := map[string]*bool{
+		sslRedirect:      &cfg.SSLRedirect,
+		forceSSLRedirect: &cfg.ForceSSLRedirect,
+		disableHTTP:      &cfg.DisableHTTP,
+		disableHTTPS:     &cfg.DisableHTTPS,
+	}
+
+	for name, field := range fields {
+		v, err := parser.GetBoolAnnotations(name, ing, redirectAnnotations.Annotations)
+		if err == nil {
+			*field = v
+		} else if errors.IsInvalidContentError(err) {
+			return nil, err
+		}
+	}
+
+	if err := s.check(cfg); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func (s *redirect) Validate(anns map[string]string) error {
+	return parser.CheckAnnotations(anns, redirectAnnotations.Annotations)
+}
+
+func (s *redirect) check(cfg *Config) error {
+	if cfg.DisableHTTP && cfg.DisableHTTPS {
+		return errors.NewInvalidAnnotationsContentError(disableHTTPS, "true, "+disableHTTP+" is already set")
+	}
+
+	if cfg.DisableHTTPS && cfg.ForceSSLRedirect {
+		return errors.NewInvalidAnnotationsContentError(forceSSLRedirect, "true, "+disableHTTPS+" is set")
+	}
+
+	return nil
+}
+
+// Redirect reports whether plain http requests are redirected to https, tls tells whether the host has a certificate.
+func (c Config) Redirect(tls bool) bool {
+	if c.DisableHTTP || c.DisableHTTPS {
+		return false
+	}
+
+	return c.ForceSSLRedirect || c.SSLRedirect && tls
+}
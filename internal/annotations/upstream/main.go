@@ -0,0 +1,202 @@
+package upstream
+
+import (
+	"fmt"
+	ingressv1 "github.com/ingoxx/ingress-nginx-kubebuilder/api/v1"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/parser"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/resolver"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/config"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/klog/v2"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	keepalive         = "upstream-keepalive"
+	keepaliveRequests = "upstream-keepalive-requests"
+	keepaliveTimeout  = "upstream-keepalive-timeout"
+	maxFails          = "upstream-max-fails"
+	failTimeout       = "upstream-fail-timeout"
+	backupService     = "upstream-backup-service"
+)
+
+// lbPolicy is the weight annotation choosing the balancing method, some of them can't have a backup server.
+const lbPolicy = "lb-policy"
+
+var upstreamAnnotations = parser.Annotation{
+	Group: "upstream",
+	Annotations: parser.AnnotationFields{
+		keepalive: {
+			Doc: "idle connections to the backends kept by each worker, `0` opens a connection per request, optional",
+		},
+		keepaliveRequests: {
+			Doc: "requests sent over a kept connection before it is closed, optional",
+		},
+		keepaliveTimeout: {
+			Doc: "how long an idle connection to the backends is kept, e.g: `60s`, optional",
+		},
+		maxFails: {
+			Doc: "failed attempts within upstream-fail-timeout taking a backend out for upstream-fail-timeout, `0` never does, optional",
+		},
+		failTimeout: {
+			Doc: "window of upstream-max-fails and how long a failing backend is taken out, e.g: `10s`, optional",
+		},
+		backupService: {
+			Doc: "Service of the ingress namespace receiving the requests when every backend is down, `name` or `name:port`, optional",
+		},
+	},
+}
+
+var nginxTime = regexp.MustCompile(`^[0-9]+(ms|s|m|h|d)?$`)
+
+type Config struct {
+	Keepalive         int    `json:"keepalive"`
+	KeepaliveRequests int    `json:"keepalive-requests"`
+	KeepaliveTimeout  string `json:"keepalive-timeout"`
+	MaxFails          int    `json:"max-fails"`
+	FailTimeout       string `json:"fail-timeout"`
+	// Backup is the address of the backup server
+	Backup string `json:"backup"`
+}
+
+// ServerParams returns the parameters of the servers of the upstream blocks.
+func (c Config) ServerParams() string {
+	return fmt.Sprintf("max_fails=%d fail_timeout=%s", c.MaxFails, c.FailTimeout)
+}
+
+type upstream struct {
+	r resolver.Resolver
+}
+
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return &upstream{r: r}
+}
+
+func (u *upstream) Parse(ing *ingressv1.Ingress) (interface{}, error) {
+	cfg := &Config{
+		Keepalive:         config.UpstreamKeepalive,
+		KeepaliveRequests: config.UpstreamKeepaliveRequests,
+		KeepaliveTimeout:  config.UpstreamKeepaliveTimeout,
+		MaxFails:          config.UpstreamMaxFails,
+		FailTimeout:       config.UpstreamFailTimeout,
+	}
+
+	ints := map[string]*int{
+		keepalive:         &cfg.Keepalive,
+		keepaliveRequests: &cfg.KeepaliveRequests,
+		maxFails:          &cfg.MaxFails,
+	}
+	for name, field := range ints {
+		v, err := parser.GetIntAnnotation(name, ing, upstreamAnnotations.Annotations)
+		if err == nil {
+			*field = v
+		} else if errors.IsInvalidContentError(err) {
+			return nil, err
+		}
+	}
+
+	times := map[string]*string{
+		keepaliveTimeout: &cfg.KeepaliveTimeout,
+		failTimeout:      &cfg.FailTimeout,
+	}
+	for name, field := range times {
+		v, err := parser.GetStringAnnotation(name, ing, upstreamAnnotations.Annotations)
+		if err != nil {
+			if errors.IsValidationError(err) {
+				klog.Warningf("%s is invalid, defaulting to %s", name, *field)
+			}
+		}
+		if v != "" {
+			*field = v
+		}
+	}
+
+	if name, err := check(cfg); err != nil {
+		return nil, errors.NewInvalidAnnotationsContentError(name, err.Error())
+	}
+
+	backup, err := parser.GetStringAnnotation(backupService, ing, upstreamAnnotations.Annotations)
+	if err != nil {
+		if errors.IsValidationError(err) {
+			klog.Warningf("%s is invalid, defaulting to empty", backupService)
+		}
+	}
+	if backup != "" {
+		switch policy := ing.GetAnnotations()[parser.GetAnnotationWithPrefix(lbPolicy)]; {
+		case strings.HasPrefix(policy, "hash"), strings.HasPrefix(policy, "ip_hash"), strings.HasPrefix(policy, "random"):
+			return nil, errors.NewInvalidAnnotationsContentError(backupService, fmt.Sprintf("%s, %s %s has no backup server", backup, lbPolicy, policy))
+		}
+
+		if cfg.Backup, err = u.serviceAddress(backup, ing.Namespace); err != nil {
+			return nil, err
+		}
+	}
+
+	return cfg, nil
+}
+
+func (u *upstream) Validate(anns map[string]string) error {
+	return parser.CheckAnnotations(anns, upstreamAnnotations.Annotations)
+}
+
+// serviceAddress resolves name or name:port of the ingress namespace to the address of the Service, the first port
+// of the Service is used when none is given.
+func (u *upstream) serviceAddress(backend, namespace string) (string, error) {
+	name, port, _ := strings.Cut(backend, ":")
+	if len(validation.IsDNS1035Label(name)) > 0 {
+		return "", errors.NewInvalidAnnotationsContentError(backupService, backend)
+	}
+
+	if u.r == nil {
+		return "", errors.NewNotSatisfiableError(fmt.Sprintf("service: %s of %s can't be read", name, backupService))
+	}
+
+	svc, err := u.r.GetService(name)
+	if err != nil {
+		return "", errors.NewNotSatisfiableError(err.Error())
+	}
+
+	for _, p := range svc.Spec.Ports {
+		if port == "" || port == p.Name || port == strconv.Itoa(int(p.Port)) {
+			return fmt.Sprintf("%s.%s.svc:%d", name, namespace, p.Port), nil
+		}
+	}
+
+	return "", errors.NewNotSatisfiableError(fmt.Sprintf("port: %s of %s not exists in service: %s, namespace: %s", port, backupService, name, namespace))
+}
+
+// check returns the setting cfg gets wrong with the reason.
+func check(cfg *Config) (string, error) {
+	switch {
+	case cfg.Keepalive < 0:
+		return keepalive, fmt.Errorf("negative %d", cfg.Keepalive)
+	case cfg.KeepaliveRequests < 1:
+		return keepaliveRequests, fmt.Errorf("%d is not positive", cfg.KeepaliveRequests)
+	case !nginxTime.MatchString(cfg.KeepaliveTimeout):
+		return keepaliveTimeout, fmt.Errorf("%s is not a time, e.g. 60s", cfg.KeepaliveTimeout)
+	case cfg.MaxFails < 0:
+		return maxFails, fmt.Errorf("negative %d", cfg.MaxFails)
+	case !nginxTime.MatchString(cfg.FailTimeout):
+		return failTimeout, fmt.Errorf("%s is not a time, e.g. 10s", cfg.FailTimeout)
+	}
+
+	return "", nil
+}
+
+// ValidateDefaults checks the global upstream settings given on the command line.
+func ValidateDefaults() error {
+	if name, err := check(&Config{
+		Keepalive:         config.UpstreamKeepalive,
+		KeepaliveRequests: config.UpstreamKeepaliveRequests,
+		KeepaliveTimeout:  config.UpstreamKeepaliveTimeout,
+		MaxFails:          config.UpstreamMaxFails,
+		FailTimeout:       config.UpstreamFailTimeout,
+	}); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+
+	return nil
+}
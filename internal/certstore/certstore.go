@@ -0,0 +1,136 @@
+package certstore
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/config"
+	cert "github.com/ingoxx/ingress-nginx-kubebuilder/pkg/utils/cert"
+	"k8s.io/klog/v2"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// pruneGrace keeps a bundle that was just written, the configuration referring to it may not be rendered yet.
+const pruneGrace = time.Minute
+
+// bundleName matches the files managed by the store, the other files of config.SslPath are left alone.
+var bundleName = regexp.MustCompile(`^[0-9a-f]{64}\.pem$`)
+
+var mux sync.Mutex
+
+// Write validates that key belongs to crt and stores both as one PEM bundle named by the hash of its content,
+// so the same certificate used by several objects is written once. The path is used for ssl_certificate and
+// ssl_certificate_key alike.
+func Write(crt, key []byte) (string, error) {
+	if err := cert.ValidateKeyPair(crt, key); err != nil {
+		return "", fmt.Errorf("invalid tls key pair: %v", err)
+	}
+
+	var bundle bytes.Buffer
+	bundle.Write(bytes.TrimSpace(crt))
+	bundle.WriteString("\n")
+	bundle.Write(bytes.TrimSpace(key))
+	bundle.WriteString("\n")
+
+	sum := sha256.Sum256(bundle.Bytes())
+	name := filepath.Join(config.SslPath, hex.EncodeToString(sum[:])+".pem")
+
+	mux.Lock()
+	defer mux.Unlock()
+
+	if _, err := os.Stat(name); err == nil {
+		now := time.Now()
+		return name, os.Chtimes(name, now, now)
+	}
+
+	tmp := filepath.Join(config.SslPath, "."+filepath.Base(name)+".tmp")
+	if err := os.WriteFile(tmp, bundle.Bytes(), 0600); err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(tmp, name); err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+// Prune removes the bundles that no rendered configuration refers to anymore.
+func Prune() {
+	mux.Lock()
+	defer mux.Unlock()
+
+	entries, err := os.ReadDir(config.SslPath)
+	if err != nil {
+		klog.ErrorS(err, fmt.Sprintf("fail to read %s", config.SslPath))
+		return
+	}
+
+	confs, err := renderedConfs()
+	if err != nil {
+		klog.ErrorS(err, "fail to read the rendered configuration, certificates are not pruned")
+		return
+	}
+
+	for _, e := range entries {
+		if e.IsDir() || !bundleName.MatchString(e.Name()) {
+			continue
+		}
+
+		info, err := e.Info()
+		if err != nil || time.Since(info.ModTime()) < pruneGrace {
+			continue
+		}
+
+		if used(confs, e.Name()) {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(config.SslPath, e.Name())); err != nil {
+			klog.ErrorS(err, fmt.Sprintf("fail to remove unused certificate %s", e.Name()))
+			continue
+		}
+
+		klog.Infof("remove unused certificate %s", e.Name())
+	}
+}
+
+func renderedConfs() ([][]byte, error) {
+	files := []string{config.MainConf}
+	for _, dir := range []string{config.ConfDir, config.StreamConfDir} {
+		matches, err := filepath.Glob(filepath.Join(dir, "*.conf"))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+
+	var confs [][]byte
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		confs = append(confs, b)
+	}
+
+	return confs, nil
+}
+
+func used(confs [][]byte, name string) bool {
+	for _, c := range confs {
+		if bytes.Contains(c, []byte(name)) {
+			return true
+		}
+	}
+
+	return false
+}
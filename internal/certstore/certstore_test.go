@@ -0,0 +1,190 @@
+package certstore
+
+import (
+	"bytes"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/config"
+	cert "github.com/ingoxx/ingress-nginx-kubebuilder/pkg/utils/cert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// tempPaths points the nginx paths used by the store to a temp dir for the duration of the test.
+func tempPaths(t *testing.T) {
+	old := []string{config.SslPath, config.ConfDir, config.StreamConfDir, config.MainConf}
+	t.Cleanup(func() {
+		config.SslPath, config.ConfDir, config.StreamConfDir, config.MainConf = old[0], old[1], old[2], old[3]
+	})
+
+	dir := t.TempDir()
+	config.SslPath = filepath.Join(dir, "ssl")
+	config.ConfDir = filepath.Join(dir, "conf.d")
+	config.StreamConfDir = filepath.Join(dir, "stream.d")
+	config.MainConf = filepath.Join(dir, "nginx.conf")
+
+	for _, d := range []string{config.SslPath, config.ConfDir, config.StreamConfDir} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func keyPair(t *testing.T, cn string) ([]byte, []byte) {
+	crt, key, err := cert.GenerateSelfSigned(cn, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return crt, key
+}
+
+func TestWrite(t *testing.T) {
+	tempPaths(t)
+
+	crt, key := keyPair(t, "a.example.com")
+	_, otherKey := keyPair(t, "b.example.com")
+
+	tests := []struct {
+		name    string
+		crt     []byte
+		key     []byte
+		wantErr bool
+	}{
+		{name: "valid pair", crt: crt, key: key},
+		{name: "key of another certificate", crt: crt, key: otherKey, wantErr: true},
+		{name: "no key", crt: crt, wantErr: true},
+		{name: "not pem", crt: []byte("crt"), key: []byte("key"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, err := Write(tt.crt, tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Write() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if filepath.Dir(name) != config.SslPath || !bundleName.MatchString(filepath.Base(name)) {
+				t.Errorf("Write() = %s, want a sha256 named bundle in %s", name, config.SslPath)
+			}
+
+			info, err := os.Stat(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != 0600 {
+				t.Errorf("bundle mode = %v, want 0600", info.Mode().Perm())
+			}
+
+			b, err := os.ReadFile(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Contains(b, bytes.TrimSpace(tt.crt)) || !bytes.Contains(b, bytes.TrimSpace(tt.key)) {
+				t.Error("bundle does not hold the certificate and the key")
+			}
+		})
+	}
+
+	entries, err := os.ReadDir(config.SslPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("ssl dir holds %d files, want only the bundle of the valid pair", len(entries))
+	}
+}
+
+func TestWriteSamePair(t *testing.T) {
+	tempPaths(t)
+	crt, key := keyPair(t, "a.example.com")
+
+	first, err := Write(crt, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(first, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := Write(append(crt, '\n'), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second != first {
+		t.Errorf("Write() of the same pair = %s, want %s", second, first)
+	}
+
+	info, err := os.Stat(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(info.ModTime()) > pruneGrace {
+		t.Error("Write() of an existing bundle did not refresh its modification time")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	tempPaths(t)
+
+	bundle := func(c byte) string {
+		return string(bytes.Repeat([]byte{c}, 64)) + ".pem"
+	}
+
+	tests := []struct {
+		name string
+		file string
+		// conf is where the file is referred to, relative to the temp paths
+		conf string
+		age  time.Duration
+		kept bool
+	}{
+		{name: "unused", file: bundle('a'), age: time.Hour},
+		{name: "used by a server", file: bundle('b'), conf: "conf.d", age: time.Hour, kept: true},
+		{name: "used by a stream server", file: bundle('c'), conf: "stream.d", age: time.Hour, kept: true},
+		{name: "used by the main conf", file: bundle('d'), conf: "nginx.conf", age: time.Hour, kept: true},
+		{name: "unused within the grace period", file: bundle('e'), age: pruneGrace / 2, kept: true},
+		{name: "not a bundle", file: "default.pem", age: time.Hour, kept: true},
+	}
+
+	for _, tt := range tests {
+		name := filepath.Join(config.SslPath, tt.file)
+		if err := os.WriteFile(name, []byte("bundle"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		mtime := time.Now().Add(-tt.age)
+		if err := os.Chtimes(name, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+
+		directive := []byte("ssl_certificate " + name + ";\n")
+		var err error
+		switch tt.conf {
+		case "conf.d":
+			err = os.WriteFile(filepath.Join(config.ConfDir, tt.file+".conf"), directive, 0644)
+		case "stream.d":
+			err = os.WriteFile(filepath.Join(config.StreamConfDir, "stream.conf"), directive, 0644)
+		case "nginx.conf":
+			err = os.WriteFile(config.MainConf, directive, 0644)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	Prune()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := os.Stat(filepath.Join(config.SslPath, tt.file))
+			if kept := err == nil; kept != tt.kept {
+				t.Errorf("Prune() kept %s = %v, want %v", tt.file, kept, tt.kept)
+			}
+		})
+	}
+}
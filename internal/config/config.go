@@ -1,15 +1,385 @@
 package config
 
+import (
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
 const (
+	TlsCrt = "tls.crt"
+	TlsKey = "tls.key"
+)
+
+// The nginx paths below are variables so that several controller instances,
+// each with its own nginx root, can be started from the same image.
+var (
 	ConfDir        = "/etc/nginx/conf.d"
-	NginxTmpl      = "/rootfs/etc/nginx/template/nginx.tmpl"
-	ServerTmpl     = "/rootfs/etc/nginx/template/server.tmpl"
-	MainServerTmpl = "/rootfs/etc/nginx/template/mainServer.tmpl"
-	DefaultTmpl    = "/rootfs/etc/nginx/template/defaultBackend.tmpl"
+	TemplateDir    = "/rootfs/etc/nginx/template"
+	NginxTmpl      = filepath.Join(TemplateDir, "nginx.tmpl")
+	ServerTmpl     = filepath.Join(TemplateDir, "server.tmpl")
+	MainServerTmpl = filepath.Join(TemplateDir, "mainServer.tmpl")
+	DefaultTmpl    = filepath.Join(TemplateDir, "defaultBackend.tmpl")
+	GatewayTmpl    = filepath.Join(TemplateDir, "gateway.tmpl")
+	StreamTmpl     = filepath.Join(TemplateDir, "stream.tmpl")
+	AcmeTmpl       = filepath.Join(TemplateDir, "acme.tmpl")
+	StreamConfDir  = "/etc/nginx/stream.d"
+	AcmeConfDir    = "/etc/nginx/acme.d"
 	SslPath        = "/etc/nginx/ssl"
-	TlsCrt         = "tls.crt"
-	TlsKey         = "tls.key"
 	Pid            = "/var/run/nginx.pid"
 	Bin            = "/usr/sbin/nginx"
 	MainConf       = "/etc/nginx/nginx.conf"
 )
+
+// With ssl passthrough enabled the stream context owns HTTPSPort, the https servers of the http context move to
+// SSLPassthroughPort on the loopback and SSLPassthroughHopPort is the internal hop to the passthrough backends.
+var (
+	SSLPassthrough        bool
+	HTTPPort              int32 = 80
+	HTTPSPort             int32 = 443
+	SSLPassthroughPort    int32 = 442
+	SSLPassthroughHopPort int32 = 441
+)
+
+// The certificate of an ingress without spec.tls is requested from cert-manager. Unless the ingress selects its own
+// issuer, DefaultCertClusterIssuer or DefaultCertIssuer signs it; without any an Issuer is created for the ingress,
+// an ACME one registered with ACMEEmail when it is set and a self signed one otherwise.
+var (
+	DefaultCertIssuer        string
+	DefaultCertClusterIssuer string
+	ACMEEmail                string
+	ACMEServer               = "https://acme-v02.api.letsencrypt.org/directory"
+)
+
+// DefaultSSLCertificate is the namespace/name of the tls Secret served to the hosts without a certificate of their own,
+// a self signed certificate is generated when it is empty or unusable.
+var DefaultSSLCertificate string
+
+// DefaultTlsCrt is the file the default certificate is written to.
+func DefaultTlsCrt() string {
+	return filepath.Join(SslPath, "default.pem")
+}
+
+// DefaultTlsKey is the file the key of the default certificate is written to.
+func DefaultTlsKey() string {
+	return filepath.Join(SslPath, "default.key")
+}
+
+// SSLExpiryWarningDays is how many days before its expiry a served certificate is reported in Warning events.
+var SSLExpiryWarningDays = 14
+
+// The tls profiles selectable with --ssl-profile and the ssl-profile annotation.
+const (
+	SSLProfileModern       = "modern"
+	SSLProfileIntermediate = "intermediate"
+	SSLProfileLegacy       = "legacy"
+	SSLProfileCustom       = "custom"
+)
+
+// TLSProfile is the set of ssl directives rendered into an https server.
+type TLSProfile struct {
+	Protocols           string
+	Ciphers             string
+	PreferServerCiphers bool
+}
+
+// TLSProfiles follow the Mozilla server side tls recommendations, the custom profile takes its protocols
+// and ciphers from SSLProtocols and SSLCiphers or from the ssl-protocols and ssl-ciphers annotations.
+var TLSProfiles = map[string]TLSProfile{
+	SSLProfileModern: {
+		Protocols: "TLSv1.3",
+	},
+	SSLProfileIntermediate: {
+		Protocols: "TLSv1.2 TLSv1.3",
+		Ciphers: "ECDHE-ECDSA-AES128-GCM-SHA256:ECDHE-RSA-AES128-GCM-SHA256:ECDHE-ECDSA-AES256-GCM-SHA384:" +
+			"ECDHE-RSA-AES256-GCM-SHA384:ECDHE-ECDSA-CHACHA20-POLY1305:ECDHE-RSA-CHACHA20-POLY1305:" +
+			"DHE-RSA-AES128-GCM-SHA256:DHE-RSA-AES256-GCM-SHA384:DHE-RSA-CHACHA20-POLY1305",
+	},
+	// legacy keeps TLSv1 and TLSv1.1 for old clients, OpenSSL 3 only offers them at security level 0
+	SSLProfileLegacy: {
+		Protocols: "TLSv1 TLSv1.1 TLSv1.2 TLSv1.3",
+		Ciphers: "ECDHE-ECDSA-AES128-GCM-SHA256:ECDHE-RSA-AES128-GCM-SHA256:ECDHE-ECDSA-AES256-GCM-SHA384:" +
+			"ECDHE-RSA-AES256-GCM-SHA384:ECDHE-ECDSA-CHACHA20-POLY1305:ECDHE-RSA-CHACHA20-POLY1305:" +
+			"DHE-RSA-AES128-GCM-SHA256:DHE-RSA-AES256-GCM-SHA384:DHE-RSA-CHACHA20-POLY1305:" +
+			"ECDHE-ECDSA-AES128-SHA256:ECDHE-RSA-AES128-SHA256:ECDHE-ECDSA-AES128-SHA:ECDHE-RSA-AES128-SHA:" +
+			"ECDHE-ECDSA-AES256-SHA384:ECDHE-RSA-AES256-SHA384:ECDHE-ECDSA-AES256-SHA:ECDHE-RSA-AES256-SHA:" +
+			"DHE-RSA-AES128-SHA256:DHE-RSA-AES256-SHA256:AES128-GCM-SHA256:AES256-GCM-SHA384:" +
+			"AES128-SHA256:AES256-SHA256:AES128-SHA:AES256-SHA:@SECLEVEL=0",
+		PreferServerCiphers: true,
+	},
+	SSLProfileCustom: {
+		PreferServerCiphers: true,
+	},
+}
+
+// SSLProfile is the tls profile of the https servers without an ssl-profile annotation, SSLProtocols and SSLCiphers
+// override its protocols and ciphers. The HSTS and SSLRedirect settings are the defaults of the matching annotations.
+var (
+	SSLProfile            = SSLProfileIntermediate
+	SSLProtocols          string
+	SSLCiphers            string
+	HSTSMaxAge            = 15768000
+	HSTSIncludeSubdomains bool
+	HSTSPreload           bool
+	SSLRedirect           bool
+)
+
+// DefaultTLSProfile returns the profile of the https servers without an ssl-profile annotation.
+func DefaultTLSProfile() TLSProfile {
+	p := TLSProfiles[SSLProfile]
+	if SSLProtocols != "" {
+		p.Protocols = SSLProtocols
+	}
+	if SSLCiphers != "" {
+		p.Ciphers = SSLCiphers
+	}
+
+	return p
+}
+
+// CustomHTTPErrors are the comma separated upstream status codes the servers without a custom-http-errors annotation
+// send to DefaultErrorBackend, the namespace/name:port of the error page Service.
+var (
+	CustomHTTPErrors    string
+	DefaultErrorBackend string
+)
+
+// The upstream settings are the defaults of the upstream annotations. UpstreamKeepalive is the number of idle
+// connections to the backends each worker keeps, 0 opens a new connection for every request, UpstreamMaxFails
+// failed attempts within UpstreamFailTimeout take a server out for UpstreamFailTimeout.
+var (
+	UpstreamKeepalive         = 320
+	UpstreamKeepaliveRequests = 10000
+	UpstreamKeepaliveTimeout  = "60s"
+	UpstreamMaxFails          = 1
+	UpstreamFailTimeout       = "10s"
+)
+
+// HealthChecks enables the prober of the health-check-path annotation, the endpoints of the probed backends are
+// rendered into their upstreams and the failing ones are marked down.
+var HealthChecks bool
+
+// The client address of a connection from one of the ProxyRealIPCIDR trusted proxies, comma separated addresses and
+// CIDRs, is taken from its PROXY protocol header with UseProxyProtocol or from X-Forwarded-For with
+// UseForwardedHeaders. Every address is trusted when ProxyRealIPCIDR is empty, which UseForwardedHeaders refuses as any
+// client could then set its address.
+var (
+	ProxyRealIPCIDR     string
+	UseForwardedHeaders bool
+	UseProxyProtocol    bool
+)
+
+// RealIP is the realip configuration of the http context, the client address is kept when From is empty.
+type RealIP struct {
+	From      []string
+	Header    string
+	Recursive bool
+}
+
+// TrustedProxies returns the parsed ProxyRealIPCIDR.
+func TrustedProxies() ([]string, error) {
+	if strings.TrimSpace(ProxyRealIPCIDR) == "" {
+		return []string{"0.0.0.0/0", "::/0"}, nil
+	}
+
+	var from []string
+	for _, s := range strings.Split(ProxyRealIPCIDR, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+
+		if p, err := netip.ParsePrefix(s); err == nil {
+			from = append(from, p.Masked().String())
+		} else if a, err := netip.ParseAddr(s); err == nil && a.Zone() == "" {
+			from = append(from, a.String())
+		} else {
+			return nil, fmt.Errorf("invalid proxy-real-ip-cidr %q", s)
+		}
+	}
+
+	return from, nil
+}
+
+// ValidateRealIP checks the real ip settings, the https servers behind the ssl passthrough listener only get the
+// client address from the PROXY protocol and X-Forwarded-For is only taken from explicitly trusted proxies.
+func ValidateRealIP() error {
+	if UseForwardedHeaders && SSLPassthrough {
+		return fmt.Errorf("use-forwarded-headers can't be combined with ssl passthrough, use use-proxy-protocol instead")
+	}
+
+	if UseForwardedHeaders && strings.TrimSpace(ProxyRealIPCIDR) == "" {
+		return fmt.Errorf("use-forwarded-headers needs the trusted proxies in proxy-real-ip-cidr, any client could set X-Forwarded-For otherwise")
+	}
+
+	_, err := TrustedProxies()
+	return err
+}
+
+// NewRealIP returns the realip configuration of the http context. The https servers behind the ssl passthrough
+// listener always trust the PROXY protocol header of the loopback.
+func NewRealIP() RealIP {
+	var r RealIP
+	if UseProxyProtocol || UseForwardedHeaders {
+		r.From, _ = TrustedProxies()
+		r.Header = "X-Forwarded-For"
+		r.Recursive = true
+	}
+
+	if UseProxyProtocol || SSLPassthrough {
+		r.Header = "proxy_protocol"
+		r.Recursive = false
+	}
+
+	if SSLPassthrough && !slices.Contains(r.From, "0.0.0.0/0") {
+		r.From = append(r.From, "127.0.0.1")
+	}
+
+	return r
+}
+
+// GeoIP2CountryDB is the MaxMind format country database the geo-allow-countries and geo-deny-countries annotations
+// look the clients up in, GeoIP2Module the ngx_http_geoip2_module loaded when nginx isn't built with it.
+var (
+	GeoIP2CountryDB string
+	GeoIP2Module    string
+)
+
+// GeoIP2Database returns GeoIP2CountryDB when it is readable, empty otherwise.
+func GeoIP2Database() string {
+	return readable(GeoIP2CountryDB)
+}
+
+func readable(name string) string {
+	if name == "" {
+		return ""
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return ""
+	}
+	_ = f.Close()
+
+	return name
+}
+
+// ModSecurityModule is the ngx_http_modsecurity_module loaded when nginx isn't built with it, the enable-modsecurity
+// annotation renders ModSecurityRulesFile and, with enable-owasp-core-rules, OWASPCoreRulesFile into the locations.
+var (
+	ModSecurityModule    string
+	ModSecurityRulesFile = "/etc/nginx/modsecurity/modsecurity.conf"
+	OWASPCoreRulesFile   = "/etc/nginx/owasp-modsecurity-crs/nginx-modsecurity.conf"
+)
+
+// ModSecurityModulePath returns ModSecurityModule when it is readable, empty otherwise.
+func ModSecurityModulePath() string {
+	return readable(ModSecurityModule)
+}
+
+// The server-snippet and configuration-snippet annotations are refused unless AllowSnippetAnnotations is set, each
+// IngressClass is served by its own controller so the flag enables them per IngressClass. SnippetNamespaces restricts
+// them to some namespaces. Every directive of a snippet must match none of SnippetDeniedDirectives and, when it is set,
+// one of SnippetAllowedDirectives, comma separated shell patterns.
+var (
+	AllowSnippetAnnotations  bool
+	SnippetNamespaces        string
+	SnippetAllowedDirectives string
+	SnippetDeniedDirectives  string
+)
+
+// AcmeLocationPath is the prefix of the acme http01 challenges, it is neither redirected nor refused.
+const AcmeLocationPath = "/.well-known/acme-challenge/"
+
+// AcmeConf returns the file holding the challenge locations an acme solver ingress adds to the server of host,
+// the server includes every file of its host.
+func AcmeConf(host, name, namespace string) string {
+	return filepath.Join(AcmeConfDir, host+"_"+name+"-"+namespace)
+}
+
+// HTTPListen returns the parameters of the listen directives of the http servers.
+func HTTPListen() []string {
+	if UseProxyProtocol {
+		return []string{fmt.Sprintf("%d proxy_protocol", HTTPPort), fmt.Sprintf("[::]:%d proxy_protocol", HTTPPort)}
+	}
+
+	return []string{fmt.Sprintf("%d", HTTPPort), fmt.Sprintf("[::]:%d", HTTPPort)}
+}
+
+// ReservedPorts returns the ports of the http context and of the ssl passthrough listeners.
+func ReservedPorts() []int32 {
+	return []int32{HTTPPort, HTTPSPort, SSLPassthroughPort, SSLPassthroughHopPort}
+}
+
+// SSLListen returns the parameters of the listen directives of the https servers.
+func SSLListen() []string {
+	if SSLPassthrough {
+		return []string{fmt.Sprintf("127.0.0.1:%d ssl proxy_protocol", SSLPassthroughPort)}
+	}
+
+	if UseProxyProtocol {
+		return []string{fmt.Sprintf("%d ssl proxy_protocol", HTTPSPort), fmt.Sprintf("[::]:%d ssl proxy_protocol", HTTPSPort)}
+	}
+
+	return []string{fmt.Sprintf("%d ssl", HTTPSPort), fmt.Sprintf("[::]:%d ssl", HTTPSPort)}
+}
+
+// SetTemplateDir points every template path at dir.
+func SetTemplateDir(dir string) {
+	TemplateDir = dir
+	NginxTmpl = filepath.Join(dir, "nginx.tmpl")
+	ServerTmpl = filepath.Join(dir, "server.tmpl")
+	MainServerTmpl = filepath.Join(dir, "mainServer.tmpl")
+	DefaultTmpl = filepath.Join(dir, "defaultBackend.tmpl")
+	GatewayTmpl = filepath.Join(dir, "gateway.tmpl")
+	StreamTmpl = filepath.Join(dir, "stream.tmpl")
+	AcmeTmpl = filepath.Join(dir, "acme.tmpl")
+}
+
+// Main is the data the main templates (nginx.tmpl, mainServer.tmpl) are rendered with.
+type Main struct {
+	ConfDir        string
+	StreamConfDir  string
+	Pid            string
+	SSLPassthrough bool
+	DefaultTlsCrt  string
+	DefaultTlsKey  string
+	TLS            TLSProfile
+	RealIP         RealIP
+	// ProxyProtocol makes the stream context trust the PROXY protocol header of the ssl passthrough listener
+	ProxyProtocol bool
+	GeoIP2DB      string
+	// Modules are the dynamic modules loaded by the main configuration
+	Modules []string
+}
+
+func modules() []string {
+	var list []string
+	if GeoIP2Module != "" {
+		list = append(list, GeoIP2Module)
+	}
+	if m := ModSecurityModulePath(); m != "" {
+		list = append(list, m)
+	}
+
+	return list
+}
+
+func NewMain() Main {
+	return Main{
+		ConfDir:        ConfDir,
+		StreamConfDir:  StreamConfDir,
+		Pid:            Pid,
+		SSLPassthrough: SSLPassthrough,
+		DefaultTlsCrt:  DefaultTlsCrt(),
+		DefaultTlsKey:  DefaultTlsKey(),
+		TLS:            DefaultTLSProfile(),
+		RealIP:         NewRealIP(),
+		ProxyProtocol:  UseProxyProtocol,
+		GeoIP2DB:       GeoIP2Database(),
+		Modules:        modules(),
+	}
+}
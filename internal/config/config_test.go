@@ -0,0 +1,112 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+// realIPFlags sets the real ip flags for a test and restores them after it.
+func realIPFlags(t *testing.T, cidr string, forwarded, proxyProtocol, passthrough bool) {
+	t.Helper()
+
+	old := []any{ProxyRealIPCIDR, UseForwardedHeaders, UseProxyProtocol, SSLPassthrough}
+	t.Cleanup(func() {
+		ProxyRealIPCIDR, UseForwardedHeaders = old[0].(string), old[1].(bool)
+		UseProxyProtocol, SSLPassthrough = old[2].(bool), old[3].(bool)
+	})
+
+	ProxyRealIPCIDR, UseForwardedHeaders, UseProxyProtocol, SSLPassthrough = cidr, forwarded, proxyProtocol, passthrough
+}
+
+func TestTrustedProxies(t *testing.T) {
+	tests := []struct {
+		name    string
+		cidr    string
+		want    []string
+		wantErr bool
+	}{
+		{name: "empty", want: []string{"0.0.0.0/0", "::/0"}},
+		{name: "list", cidr: "10.0.0.0/8, 192.168.1.1 ,,", want: []string{"10.0.0.0/8", "192.168.1.1"}},
+		{name: "host bits", cidr: "10.1.2.3/8", want: []string{"10.0.0.0/8"}},
+		{name: "ipv6", cidr: "2001:DB8::/32,::1", want: []string{"2001:db8::/32", "::1"}},
+		{name: "zone", cidr: "fe80::1%eth0", wantErr: true},
+		{name: "hostname", cidr: "proxy.example.com", wantErr: true},
+		{name: "injection", cidr: "10.0.0.1; real_ip_header x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			realIPFlags(t, tt.cidr, false, false, false)
+
+			got, err := TrustedProxies()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TrustedProxies() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("TrustedProxies() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRealIP(t *testing.T) {
+	tests := []struct {
+		name          string
+		cidr          string
+		forwarded     bool
+		proxyProtocol bool
+		passthrough   bool
+		wantErr       bool
+	}{
+		{name: "disabled"},
+		{name: "forwarded headers", cidr: "10.0.0.0/8", forwarded: true},
+		{name: "forwarded headers trusting everyone", forwarded: true, wantErr: true},
+		{name: "forwarded headers with passthrough", cidr: "10.0.0.0/8", forwarded: true, passthrough: true, wantErr: true},
+		{name: "proxy protocol with passthrough", proxyProtocol: true, passthrough: true},
+		{name: "invalid cidr", cidr: "10.0.0.0/33", proxyProtocol: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			realIPFlags(t, tt.cidr, tt.forwarded, tt.proxyProtocol, tt.passthrough)
+
+			if err := ValidateRealIP(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateRealIP() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewRealIP(t *testing.T) {
+	tests := []struct {
+		name          string
+		cidr          string
+		forwarded     bool
+		proxyProtocol bool
+		passthrough   bool
+		want          RealIP
+	}{
+		{name: "disabled"},
+		{name: "forwarded headers", cidr: "10.0.0.0/8", forwarded: true,
+			want: RealIP{From: []string{"10.0.0.0/8"}, Header: "X-Forwarded-For", Recursive: true}},
+		{name: "proxy protocol", cidr: "10.0.0.0/8", proxyProtocol: true,
+			want: RealIP{From: []string{"10.0.0.0/8"}, Header: "proxy_protocol"}},
+		{name: "passthrough trusts the loopback", passthrough: true,
+			want: RealIP{From: []string{"127.0.0.1"}, Header: "proxy_protocol"}},
+		{name: "passthrough with proxy protocol", cidr: "10.0.0.0/8", proxyProtocol: true, passthrough: true,
+			want: RealIP{From: []string{"10.0.0.0/8", "127.0.0.1"}, Header: "proxy_protocol"}},
+		{name: "passthrough with every proxy trusted", proxyProtocol: true, passthrough: true,
+			want: RealIP{From: []string{"0.0.0.0/0", "::/0"}, Header: "proxy_protocol"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			realIPFlags(t, tt.cidr, tt.forwarded, tt.proxyProtocol, tt.passthrough)
+
+			got := NewRealIP()
+			if !slices.Equal(got.From, tt.want.From) || got.Header != tt.want.Header || got.Recursive != tt.want.Recursive {
+				t.Errorf("NewRealIP() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	ingressv1 "github.com/ingoxx/ingress-nginx-kubebuilder/api/v1"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/config"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/metrics"
+	cert "github.com/ingoxx/ingress-nginx-kubebuilder/pkg/utils/cert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sort"
+	"strings"
+	"time"
+)
+
+//+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+
+// reportCertificates exports the expiry of the certificates served for ic, warns about the ones expiring soon or
+// with an incomplete chain and reports the ones that were not rendered. The events are recorded on obj, the
+// object ic was converted from. It returns when ic must be checked again for a certificate to enter the warning
+// period or to expire, 0 when none will.
+func (r *IngressReconciler) reportCertificates(ctx context.Context, ic *ingressv1.Ingress, obj client.Object, certs map[string]*cert.Info, rejected map[string]error) time.Duration {
+	var next time.Duration
+	metrics.DeleteCertificateExpiry(ic.Namespace, ic.Name)
+
+	warning := time.Duration(config.SSLExpiryWarningDays) * 24 * time.Hour
+	for _, host := range sortedHosts(certs) {
+		info := certs[host]
+		metrics.SetCertificateExpiry(ic.Namespace, ic.Name, host, info.NotAfter)
+
+		left := time.Until(info.NotAfter)
+		if left <= 0 {
+			r.event(obj, "CertificateExpired", "certificate of host %s issued by %s expired at %s", host, info.Issuer, info.NotAfter.Format(time.RFC3339))
+		} else if left < warning {
+			r.event(obj, "CertificateExpiring", "certificate of host %s issued by %s expires at %s", host, info.Issuer, info.NotAfter.Format(time.RFC3339))
+		}
+
+		if left > warning {
+			left -= warning
+		}
+		if left > 0 && (next == 0 || left < next) {
+			next = left
+		}
+
+		if !info.ChainComplete {
+			r.event(obj, "CertificateChainIncomplete", "certificate chain of host %s issued by %s does not verify up to a trusted root", host, info.Issuer)
+		}
+	}
+
+	var messages []string
+	var reason = "Rejected"
+	for _, host := range sortedHosts(rejected) {
+		klog.ErrorS(rejected[host], fmt.Sprintf("certificate of host: %s is not rendered in ingress: %s, namespace: %s", host, ic.Name, ic.Namespace))
+		if errors.Is(rejected[host], errRefNotPermitted) {
+			reason = "RefNotPermitted"
+			r.event(obj, "CertificateRefNotPermitted", "https is not served for host %s: %v", host, rejected[host])
+		} else {
+			r.event(obj, "CertificateRejected", "https is not served for host %s: %v", host, rejected[host])
+		}
+		messages = append(messages, fmt.Sprintf("%s: %v", host, rejected[host]))
+	}
+
+	ing, ok := obj.(*ingressv1.Ingress)
+	if !ok {
+		return next
+	}
+
+	cond := metav1.Condition{
+		Type:               ingressv1.IngressCertificatesValid,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Valid",
+		Message:            "the certificate of every tls host is rendered",
+		ObservedGeneration: ing.Generation,
+	}
+
+	if len(messages) > 0 {
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = reason
+		cond.Message = strings.Join(messages, "; ")
+	}
+
+	if err := r.updateCondition(ctx, ing, cond, len(certs)+len(rejected) > 0); err != nil {
+		klog.ErrorS(err, fmt.Sprintf("fail to update status of ingress: %s, namespace: %s", ing.Name, ing.Namespace))
+	}
+
+	return next
+}
+
+// updateCondition sets cond, or removes it when it doesn't apply, e.g. the certificates of an ingress without tls host.
+func (r *IngressReconciler) updateCondition(ctx context.Context, ing *ingressv1.Ingress, cond metav1.Condition, applies bool) error {
+	c := meta.FindStatusCondition(ing.Status.Conditions, cond.Type)
+
+	if !applies {
+		if c == nil {
+			return nil
+		}
+		meta.RemoveStatusCondition(&ing.Status.Conditions, cond.Type)
+
+		return r.Status().Update(ctx, ing)
+	}
+
+	if c != nil && c.Status == cond.Status && c.Reason == cond.Reason && c.Message == cond.Message && c.ObservedGeneration == cond.ObservedGeneration {
+		return nil
+	}
+
+	meta.SetStatusCondition(&ing.Status.Conditions, cond)
+
+	return r.Status().Update(ctx, ing)
+}
+
+func (r *IngressReconciler) event(obj client.Object, reason, messageFmt string, args ...interface{}) {
+	if r.Recorder == nil {
+		return
+	}
+
+	r.Recorder.Eventf(obj, v1.EventTypeWarning, reason, messageFmt, args...)
+}
+
+func sortedHosts[T any](m map[string]T) []string {
+	hosts := make([]string, 0, len(m))
+	for h := range m {
+		hosts = append(hosts, h)
+	}
+	sort.Strings(hosts)
+
+	return hosts
+}
@@ -1,7 +1,7 @@
 package controller
 
 const (
-	controller         = "kubebuilder.io/ingress-nginx"
-	nginxAnnotationKey = "kubernetes.io/ingress.class"
-	nginxAnnotationVal = "kubebuilder-nginx"
+	DefaultControllerClass = "kubebuilder.io/ingress-nginx"
+	DefaultIngressClass    = "kubebuilder-nginx"
+	nginxAnnotationKey     = "kubernetes.io/ingress.class"
 )
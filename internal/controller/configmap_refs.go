@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	ingressv1 "github.com/ingoxx/ingress-nginx-kubebuilder/api/v1"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/headers"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/modsecurity"
+	netv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"slices"
+)
+
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch
+
+// configMapRequests maps a ConfigMap to the served ingresses of its namespace sending its headers to their backends
+// or using its ModSecurity rules, in their annotations or those of their path overrides.
+func (r *IngressReconciler) configMapRequests(ctx context.Context, obj client.Object, key types.NamespacedName, className string, anns map[string]string, overrides ...ingressv1.PathOverride) []reconcile.Request {
+	if key.Namespace != obj.GetNamespace() {
+		return nil
+	}
+
+	refs := slices.ContainsFunc(overrides, func(o ingressv1.PathOverride) bool { return refersConfigMap(o.Annotations, obj.GetName()) })
+	if !refs && !refersConfigMap(anns, obj.GetName()) {
+		return nil
+	}
+
+	if !r.matchClass(ctx, className, anns) {
+		return nil
+	}
+
+	return []reconcile.Request{{NamespacedName: key}}
+}
+
+func refersConfigMap(anns map[string]string, name string) bool {
+	return headers.ConfigMapRef(anns) == name || modsecurity.ConfigMapRef(anns) == name
+}
+
+func (r *IngressReconciler) ingressesForConfigMap(ctx context.Context, obj client.Object) []reconcile.Request {
+	var list ingressv1.IngressList
+	if err := r.List(ctx, &list, client.InNamespace(obj.GetNamespace())); err != nil {
+		klog.ErrorS(err, fmt.Sprintf("fail to list ingresses using configmap: %s, namespace: %s", obj.GetName(), obj.GetNamespace()))
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, ing := range list.Items {
+		key := types.NamespacedName{Name: ing.Name, Namespace: ing.Namespace}
+		requests = append(requests, r.configMapRequests(ctx, obj, key, ing.Spec.IngressClassName, ing.GetAnnotations(), ing.Spec.PathOverrides...)...)
+	}
+
+	return requests
+}
+
+func (r *CoreIngressReconciler) ingressesForConfigMap(ctx context.Context, obj client.Object) []reconcile.Request {
+	var list netv1.IngressList
+	if err := r.List(ctx, &list, client.InNamespace(obj.GetNamespace())); err != nil {
+		klog.ErrorS(err, fmt.Sprintf("fail to list ingresses using configmap: %s, namespace: %s", obj.GetName(), obj.GetNamespace()))
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, ing := range list.Items {
+		var className string
+		if ing.Spec.IngressClassName != nil {
+			className = *ing.Spec.IngressClassName
+		}
+
+		key := types.NamespacedName{Name: ing.Name, Namespace: ing.Namespace}
+		requests = append(requests, r.configMapRequests(ctx, obj, key, className, ing.GetAnnotations())...)
+	}
+
+	return requests
+}
@@ -0,0 +1,201 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	ingressv1 "github.com/ingoxx/ingress-nginx-kubebuilder/api/v1"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/config"
+	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	netv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"net"
+	"reflect"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+	"strings"
+)
+
+// CoreIngressReconciler reconciles the standard networking.k8s.io/v1 Ingress by converting it into the
+// internal Ingress model and handing it to the same pipeline as the custom resource.
+type CoreIngressReconciler struct {
+	IngressReconciler
+	// PublishService is the namespace/name of the Service whose load balancer address is written to status
+	PublishService string
+	// PublishStatusAddress is a comma separated list of addresses written to status when PublishService is empty
+	PublishStatusAddress string
+}
+
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses/status,verbs=get;update;patch
+
+func (r *CoreIngressReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var ing = new(netv1.Ingress)
+
+	if err := r.Get(ctx, req.NamespacedName, ing); err != nil {
+		if !errors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+
+		klog.Infof("ingress resource %s not found in namesapce %s, maybe has been deleted", req.NamespacedName.Name, req.NamespacedName.Namespace)
+		shadowed, err := r.shadowed(ctx, req.NamespacedName)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if !shadowed {
+			r.clearConf(req.NamespacedName)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	shadowed, err := r.shadowed(ctx, req.NamespacedName)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if shadowed {
+		klog.Warningf("ingress: %s, namespace: %s is not served, an Ingress of %s has the same name", ing.Name, ing.Namespace, ingressv1.GroupVersion.Group)
+		r.event(ing, "NameConflict", "an Ingress of %s has the same name and takes precedence, this ingress is not served", ingressv1.GroupVersion.Group)
+		return ctrl.Result{}, nil
+	}
+
+	result, served, err := r.sync(ctx, ingressv1.ConvertFromNetworking(ing), ing)
+	if err != nil || !served {
+		return result, err
+	}
+
+	if err := r.updateStatus(ctx, ing); err != nil {
+		klog.ErrorS(err, fmt.Sprintf("fail to update status of ingress: %s, namespace: %s", ing.Name, ing.Namespace))
+	}
+
+	return result, nil
+}
+
+// shadowed reports whether an Ingress custom resource served by this instance has the name of key. Both would render
+// the same conf, cert-manager objects and passthrough hosts, so the custom resource takes precedence. The error of
+// any lookup but a not found one is returned, the conf of the custom resource must not be touched on a guess.
+func (r *CoreIngressReconciler) shadowed(ctx context.Context, key types.NamespacedName) (bool, error) {
+	cr := new(ingressv1.Ingress)
+	if err := r.Get(ctx, key, cr); err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return r.matchClass(ctx, cr.Spec.IngressClassName, cr.GetAnnotations()), nil
+}
+
+// ingressesForCustomResource maps an Ingress custom resource of this instance to the core Ingress of the same name,
+// it is served again once the custom resource is deleted or moved to another class.
+func (r *CoreIngressReconciler) ingressesForCustomResource(ctx context.Context, obj client.Object) []reconcile.Request {
+	key := types.NamespacedName{Name: obj.GetName(), Namespace: obj.GetNamespace()}
+	if err := r.Get(ctx, key, new(netv1.Ingress)); err != nil {
+		return nil
+	}
+
+	return []reconcile.Request{{NamespacedName: key}}
+}
+
+func (r *CoreIngressReconciler) updateStatus(ctx context.Context, ing *netv1.Ingress) error {
+	lb, err := publishedAddresses(ctx, r.Client, r.PublishService, r.PublishStatusAddress)
+	if err != nil {
+		return err
+	}
+
+	if reflect.DeepEqual(ing.Status.LoadBalancer.Ingress, lb) {
+		return nil
+	}
+
+	ing.Status.LoadBalancer.Ingress = lb
+
+	return r.Status().Update(ctx, ing)
+}
+
+// publishedAddresses returns the load balancer addresses of publishService, or the static addresses when it is empty.
+func publishedAddresses(ctx context.Context, c client.Client, publishService, addresses string) ([]netv1.IngressLoadBalancerIngress, error) {
+	var lb []netv1.IngressLoadBalancerIngress
+
+	if publishService == "" {
+		for _, addr := range strings.Split(addresses, ",") {
+			addr = strings.TrimSpace(addr)
+			if addr == "" {
+				continue
+			}
+			if net.ParseIP(addr) != nil {
+				lb = append(lb, netv1.IngressLoadBalancerIngress{IP: addr})
+			} else {
+				lb = append(lb, netv1.IngressLoadBalancerIngress{Hostname: addr})
+			}
+		}
+
+		return lb, nil
+	}
+
+	ns, name, found := strings.Cut(publishService, "/")
+	if !found {
+		return lb, fmt.Errorf("publish service: %s is not in namespace/name format", publishService)
+	}
+
+	svc := new(v1.Service)
+	if err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: ns}, svc); err != nil {
+		return lb, err
+	}
+
+	for _, v := range svc.Status.LoadBalancer.Ingress {
+		lb = append(lb, netv1.IngressLoadBalancerIngress{IP: v.IP, Hostname: v.Hostname})
+	}
+
+	if len(lb) == 0 {
+		for _, ip := range svc.Spec.ExternalIPs {
+			lb = append(lb, netv1.IngressLoadBalancerIngress{IP: ip})
+		}
+	}
+
+	return lb, nil
+}
+
+func (r *CoreIngressReconciler) classPredicate() predicate.Funcs {
+	return newClassPredicate(func(obj client.Object) bool {
+		ing, ok := obj.(*netv1.Ingress)
+		if !ok {
+			return false
+		}
+
+		var className string
+		if ing.Spec.IngressClassName != nil {
+			className = *ing.Spec.IngressClassName
+		}
+
+		return r.matchClass(context.Background(), className, ing.GetAnnotations())
+	})
+}
+
+// SetupWithManager sets up the controller with the Manager, nginx itself is started by the IngressReconciler.
+func (r *CoreIngressReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.dynamicClient = r.createDynamicClientSet()
+	b := ctrl.NewControllerManagedBy(mgr).
+		Named("coreingress").
+		For(&netv1.Ingress{}, builder.WithPredicates(r.classPredicate())).
+		Watches(&v1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.ingressesForSecret)).
+		Watches(&ingressv1.SecretGrant{}, handler.EnqueueRequestsFromMapFunc(r.ingressesForSecret)).
+		Watches(&v1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.ingressesForConfigMap)).
+		Watches(&ingressv1.Ingress{}, handler.EnqueueRequestsFromMapFunc(r.ingressesForCustomResource),
+			builder.WithPredicates(r.IngressReconciler.classPredicate()))
+
+	if config.HealthChecks {
+		r.healthEvents = make(chan event.GenericEvent, healthEventsSize)
+		b = b.WatchesRawSource(source.Channel(r.healthEvents, &handler.EnqueueRequestForObject{})).
+			Watches(&discoveryv1.EndpointSlice{}, handler.EnqueueRequestsFromMapFunc(r.ingressesForEndpointSlice))
+	}
+
+	return b.Complete(r)
+}
@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/config"
+	cert "github.com/ingoxx/ingress-nginx-kubebuilder/pkg/utils/cert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"os"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"time"
+)
+
+const (
+	fallbackCommonName = "ingress.local"
+	fallbackValidFor   = 10 * 365 * 24 * time.Hour
+)
+
+// DefaultCertificateReconciler writes the tls Secret given by --default-ssl-certificate to the default certificate
+// files, nginx is reloaded by the watcher of the ssl directory.
+type DefaultCertificateReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// Secret is the tls Secret served to the hosts without a certificate of their own
+	Secret types.NamespacedName
+}
+
+func (r *DefaultCertificateReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	secret := new(v1.Secret)
+	if err := r.Get(ctx, req.NamespacedName, secret); err != nil {
+		if errors.IsNotFound(err) {
+			klog.Infof("default ssl certificate secret: %s not found, serving a self signed certificate", req.NamespacedName)
+			if err := writeFallbackCertificate(); err != nil {
+				klog.ErrorS(err, "fail to generate the fallback ssl certificate")
+			}
+			return ctrl.Result{}, nil
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	crt, key := secret.Data[config.TlsCrt], secret.Data[config.TlsKey]
+	if err := cert.ValidateKeyPair(crt, key); err != nil {
+		klog.ErrorS(err, fmt.Sprintf("default ssl certificate secret: %s is invalid, keeping the current certificate", req.NamespacedName))
+		return ctrl.Result{}, nil
+	}
+
+	if err := writeDefaultCertificate(crt, key); err != nil {
+		klog.ErrorS(err, fmt.Sprintf("fail to write the default ssl certificate from secret: %s", req.NamespacedName))
+		return ctrl.Result{RequeueAfter: time.Second * time.Duration(15)}, nil
+	}
+
+	klog.Infof("update default ssl certificate from secret: %s successfully", req.NamespacedName)
+
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *DefaultCertificateReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("defaultcertificate").
+		For(&v1.Secret{}, builder.WithPredicates(predicate.NewPredicateFuncs(func(obj client.Object) bool {
+			return obj.GetNamespace() == r.Secret.Namespace && obj.GetName() == r.Secret.Name
+		}))).
+		Complete(r)
+}
+
+// ensureDefaultCertificate makes sure nginx starts with a usable default certificate, a self signed one is generated
+// when the files are missing or invalid.
+func ensureDefaultCertificate() error {
+	if err := os.MkdirAll(config.SslPath, 0755); err != nil {
+		return err
+	}
+
+	crt, crtErr := os.ReadFile(config.DefaultTlsCrt())
+	key, keyErr := os.ReadFile(config.DefaultTlsKey())
+	if crtErr == nil && keyErr == nil && cert.ValidateKeyPair(crt, key) == nil {
+		return nil
+	}
+
+	return writeFallbackCertificate()
+}
+
+func writeFallbackCertificate() error {
+	crt, key, err := cert.GenerateSelfSigned(fallbackCommonName, fallbackValidFor)
+	if err != nil {
+		return err
+	}
+
+	return writeDefaultCertificate(crt, key)
+}
+
+// writeDefaultCertificate replaces the key before the certificate, each file is renamed into place so nginx never
+// reads a partially written one.
+func writeDefaultCertificate(crt, key []byte) error {
+	if err := writeFileAtomic(config.DefaultTlsKey(), key, 0600); err != nil {
+		return err
+	}
+
+	return writeFileAtomic(config.DefaultTlsCrt(), crt, 0644)
+}
+
+func writeFileAtomic(name string, data []byte, perm os.FileMode) error {
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, name)
+}
@@ -2,8 +2,12 @@ package controller
 
 import (
 	ingressv1 "github.com/ingoxx/ingress-nginx-kubebuilder/api/v1"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/sslprofile"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/config"
 	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/nginx"
 	"github.com/ingoxx/ingress-nginx-kubebuilder/pkg/utils/template_nginx"
+	"os"
 )
 
 type ConfHandler struct {
@@ -14,14 +18,7 @@ func NewConfHandler() ConfHandler {
 }
 
 func (c ConfHandler) UpdateDefaultConf(parser *template_nginx.RenderTemplate) error {
-	var servers = new(ingressv1.Server)
-	var cfg = struct {
-		Server *ingressv1.Server
-	}{
-		Server: servers,
-	}
-
-	if err := parser.Render(cfg); err != nil {
+	if err := parser.Render(c.defaultData()); err != nil {
 		return err
 	}
 
@@ -31,3 +28,29 @@ func (c ConfHandler) UpdateDefaultConf(parser *template_nginx.RenderTemplate) er
 
 	return nil
 }
+
+// WriteDefaultConf renders the main conf without reloading nginx, it is used before nginx starts.
+func (c ConfHandler) WriteDefaultConf(parser *template_nginx.RenderTemplate) error {
+	if err := parser.Render(c.defaultData()); err != nil {
+		return err
+	}
+
+	return os.Rename(parser.GenerateName+"-test.conf", parser.GenerateName+".conf")
+}
+
+func (c ConfHandler) defaultData() interface{} {
+	var servers = new(ingressv1.Server)
+	var cfg = struct {
+		Server      *ingressv1.Server
+		Annotations *annotations.Ingress
+		SSLListen   []string
+		HTTPListen  []string
+	}{
+		Server:      servers,
+		Annotations: &annotations.Ingress{SSLProfile: sslprofile.Default()},
+		SSLListen:   config.SSLListen(),
+		HTTPListen:  config.HTTPListen(),
+	}
+
+	return cfg
+}
@@ -0,0 +1,481 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	ingressv1 "github.com/ingoxx/ingress-nginx-kubebuilder/api/v1"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/certstore"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/config"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/gateway"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/nginx"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/pkg/utils/template_nginx"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"net"
+	"path/filepath"
+	"reflect"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"time"
+)
+
+// GatewayReconciler renders the Gateways of a GatewayClass handled by this controller, together with the
+// HTTPRoutes attached to them, and reports Gateway API status conditions.
+type GatewayReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// ControllerName is the spec.controllerName a GatewayClass must carry to be handled by this instance
+	ControllerName       string
+	PublishService       string
+	PublishStatusAddress string
+}
+
+//+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=gatewayclasses;gateways;httproutes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=gatewayclasses/status;gateways/status;httproutes/status,verbs=get;update;patch
+
+func (r *GatewayReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	conf := filepath.Join(config.ConfDir, "gateway-"+req.Name+"-"+req.Namespace)
+
+	gwObj := newUnstructured(gateway.GatewayGVK)
+	if err := r.Get(ctx, req.NamespacedName, gwObj); err != nil {
+		klog.Infof("gateway %s not found in namesapce %s, maybe has been deleted", req.Name, req.Namespace)
+		nginx.CleanConf(conf + ".conf")
+		return ctrl.Result{}, nil
+	}
+
+	gw := new(gateway.Gateway)
+	if err := gateway.FromUnstructured(gwObj.Object, gw); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if !r.ownedClass(ctx, gw.Spec.GatewayClassName) {
+		nginx.CleanConf(conf + ".conf")
+		return ctrl.Result{}, nil
+	}
+
+	routes, routeObjs, err := r.attachedRoutes(ctx, gw)
+	if err != nil {
+		return ctrl.Result{RequeueAfter: time.Second * time.Duration(15)}, nil
+	}
+
+	res := (&gateway.Translator{
+		Gateway:      gw,
+		Routes:       routes,
+		Certificates: r.listenerCertificates(ctx, gw),
+	}).Translate()
+
+	pr := &template_nginx.RenderTemplate{
+		GenerateName:       conf,
+		RenderTemplateName: config.GatewayTmpl,
+		MainTemplateName:   config.MainServerTmpl,
+	}
+
+	var data = struct {
+		Cfg           *ingressv1.Configuration
+		SSLListen     []string
+		HTTPSPort     int32
+		ProxyProtocol bool
+	}{
+		Cfg:           res.Configuration,
+		SSLListen:     config.SSLListen(),
+		HTTPSPort:     config.HTTPSPort,
+		ProxyProtocol: config.UseProxyProtocol,
+	}
+
+	var programmed = true
+	var renderErr error
+	if renderErr = pr.Render(data); renderErr == nil {
+		renderErr = nginx.Reload(conf)
+	}
+	if renderErr != nil {
+		klog.ErrorS(renderErr, fmt.Sprintf("error in gateway: %s, namespace: %s", gw.Name, gw.Namespace))
+		programmed = false
+	}
+
+	if err := r.updateGatewayStatus(ctx, gwObj, res, programmed, renderErr); err != nil {
+		klog.ErrorS(err, fmt.Sprintf("fail to update status of gateway: %s, namespace: %s", gw.Name, gw.Namespace))
+	}
+
+	for _, obj := range routeObjs {
+		key := types.NamespacedName{Name: obj.GetName(), Namespace: obj.GetNamespace()}
+		if err := r.updateRouteStatus(ctx, obj, gw, res.Routes[key]); err != nil {
+			klog.ErrorS(err, fmt.Sprintf("fail to update status of httproute: %s, namespace: %s", key.Name, key.Namespace))
+		}
+	}
+
+	if !programmed {
+		return ctrl.Result{RequeueAfter: time.Second * time.Duration(15)}, nil
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// ownedClass reports whether the GatewayClass name is handled by this controller.
+func (r *GatewayReconciler) ownedClass(ctx context.Context, name string) bool {
+	obj := newUnstructured(gateway.GatewayClassGVK)
+	if err := r.Get(ctx, types.NamespacedName{Name: name}, obj); err != nil {
+		return false
+	}
+
+	gc := new(gateway.GatewayClass)
+	if err := gateway.FromUnstructured(obj.Object, gc); err != nil {
+		return false
+	}
+
+	return gc.Spec.ControllerName == r.controllerName()
+}
+
+func (r *GatewayReconciler) controllerName() string {
+	if r.ControllerName == "" {
+		return DefaultControllerClass
+	}
+
+	return r.ControllerName
+}
+
+// attachedRoutes lists the HTTPRoutes with a parentRef to gw.
+func (r *GatewayReconciler) attachedRoutes(ctx context.Context, gw *gateway.Gateway) ([]*gateway.HTTPRoute, []*unstructured.Unstructured, error) {
+	var routes []*gateway.HTTPRoute
+	var objs []*unstructured.Unstructured
+
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(gateway.HTTPRouteGVK.GroupVersion().WithKind(gateway.HTTPRouteGVK.Kind + "List"))
+	if err := r.List(ctx, list); err != nil {
+		klog.ErrorS(err, "fail to list httproutes")
+		return nil, nil, err
+	}
+
+	for i := range list.Items {
+		obj := &list.Items[i]
+		route := new(gateway.HTTPRoute)
+		if err := gateway.FromUnstructured(obj.Object, route); err != nil {
+			klog.ErrorS(err, fmt.Sprintf("invalid httproute: %s, namespace: %s", obj.GetName(), obj.GetNamespace()))
+			continue
+		}
+
+		if referencesGateway(route, gw.Name, gw.Namespace) {
+			routes = append(routes, route)
+			objs = append(objs, obj)
+		}
+	}
+
+	return routes, objs, nil
+}
+
+// listenerCertificates writes the certificate of every HTTPS listener to the certificate store.
+func (r *GatewayReconciler) listenerCertificates(ctx context.Context, gw *gateway.Gateway) map[string]ingressv1.SSLCert {
+	certs := make(map[string]ingressv1.SSLCert)
+
+	for _, l := range gw.Spec.Listeners {
+		if l.Protocol != gateway.HTTPSProtocolType || l.TLS == nil || len(l.TLS.CertificateRefs) == 0 {
+			continue
+		}
+
+		ref := l.TLS.CertificateRefs[0]
+		if ref.Namespace != nil && *ref.Namespace != gw.Namespace {
+			klog.Warningf("certificateRef %s/%s of listener %s is in another namespace", *ref.Namespace, ref.Name, l.Name)
+			continue
+		}
+
+		secret := new(v1.Secret)
+		if err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: gw.Namespace}, secret); err != nil {
+			klog.ErrorS(err, fmt.Sprintf("fail to get secret: %s, in namespace: %s", ref.Name, gw.Namespace))
+			continue
+		}
+
+		bundle, err := certstore.Write(secret.Data[config.TlsCrt], secret.Data[config.TlsKey])
+		if err != nil {
+			klog.ErrorS(err, fmt.Sprintf("invalid certificate in secret: %s, in namespace: %s", ref.Name, gw.Namespace))
+			continue
+		}
+
+		certs[l.Name] = ingressv1.SSLCert{TlsCrt: bundle, TlsKey: bundle, TlsNoPass: true}
+	}
+
+	return certs
+}
+
+func (r *GatewayReconciler) updateGatewayStatus(ctx context.Context, obj *unstructured.Unstructured, res *gateway.Result, programmed bool, renderErr error) error {
+	generation := obj.GetGeneration()
+	conds := getConditions(obj.Object, "status", "conditions")
+
+	meta.SetStatusCondition(&conds, metav1.Condition{
+		Type:               "Accepted",
+		Status:             metav1.ConditionTrue,
+		Reason:             "Accepted",
+		ObservedGeneration: generation,
+	})
+
+	programmedCond := metav1.Condition{
+		Type:               "Programmed",
+		Status:             metav1.ConditionTrue,
+		Reason:             "Programmed",
+		ObservedGeneration: generation,
+	}
+	if !programmed {
+		programmedCond.Status = metav1.ConditionFalse
+		programmedCond.Reason = "Invalid"
+		programmedCond.Message = renderErr.Error()
+	}
+	meta.SetStatusCondition(&conds, programmedCond)
+
+	status := map[string]interface{}{}
+	if current, found, _ := unstructured.NestedMap(obj.Object, "status"); found {
+		status = current
+	}
+	before := runtime.DeepCopyJSONValue(status)
+
+	if err := setConditions(status, conds, "conditions"); err != nil {
+		return err
+	}
+
+	var listeners []interface{}
+	for _, ls := range res.Listeners {
+		lconds := getListenerConditions(obj.Object, ls.Name)
+		accepted := metav1.Condition{Type: "Accepted", Status: metav1.ConditionTrue, Reason: "Accepted", ObservedGeneration: generation}
+		lprogrammed := metav1.Condition{Type: "Programmed", Status: metav1.ConditionTrue, Reason: "Programmed", ObservedGeneration: generation}
+		resolved := metav1.Condition{Type: "ResolvedRefs", Status: metav1.ConditionTrue, Reason: "ResolvedRefs", ObservedGeneration: generation}
+		if !ls.Accepted {
+			accepted.Status, accepted.Reason, accepted.Message = metav1.ConditionFalse, ls.Reason, ls.Message
+			lprogrammed.Status, lprogrammed.Reason = metav1.ConditionFalse, "Invalid"
+			if ls.Reason == "InvalidCertificateRef" {
+				resolved.Status, resolved.Reason, resolved.Message = metav1.ConditionFalse, ls.Reason, ls.Message
+			}
+		} else if !programmed {
+			lprogrammed.Status, lprogrammed.Reason = metav1.ConditionFalse, "Invalid"
+		}
+		meta.SetStatusCondition(&lconds, accepted)
+		meta.SetStatusCondition(&lconds, lprogrammed)
+		meta.SetStatusCondition(&lconds, resolved)
+
+		l := map[string]interface{}{
+			"name":           ls.Name,
+			"attachedRoutes": int64(ls.AttachedRoutes),
+			"supportedKinds": []interface{}{
+				map[string]interface{}{"group": gateway.Group, "kind": gateway.HTTPRouteGVK.Kind},
+			},
+		}
+		if err := setConditions(l, lconds, "conditions"); err != nil {
+			return err
+		}
+		listeners = append(listeners, l)
+	}
+	status["listeners"] = listeners
+
+	lb, err := publishedAddresses(ctx, r.Client, r.PublishService, r.PublishStatusAddress)
+	if err != nil {
+		klog.ErrorS(err, "fail to get published addresses")
+	}
+	var addresses []interface{}
+	for _, v := range lb {
+		if v.IP != "" && net.ParseIP(v.IP) != nil {
+			addresses = append(addresses, map[string]interface{}{"type": "IPAddress", "value": v.IP})
+		} else if v.Hostname != "" {
+			addresses = append(addresses, map[string]interface{}{"type": "Hostname", "value": v.Hostname})
+		}
+	}
+	if len(addresses) > 0 {
+		status["addresses"] = addresses
+	}
+
+	if reflect.DeepEqual(before, runtime.DeepCopyJSONValue(status)) {
+		return nil
+	}
+
+	obj.Object["status"] = status
+
+	return r.Status().Update(ctx, obj)
+}
+
+// updateRouteStatus writes the parent status of gw, entries of other parents and controllers are kept.
+func (r *GatewayReconciler) updateRouteStatus(ctx context.Context, obj *unstructured.Unstructured, gw *gateway.Gateway, rs *gateway.RouteStatus) error {
+	if rs == nil {
+		return nil
+	}
+
+	generation := obj.GetGeneration()
+	parents, _, _ := unstructured.NestedSlice(obj.Object, "status", "parents")
+	before := runtime.DeepCopyJSONValue(parents)
+
+	var conds []metav1.Condition
+	var kept []interface{}
+	for _, p := range parents {
+		pm, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(pm, "parentRef", "name")
+		ns, _, _ := unstructured.NestedString(pm, "parentRef", "namespace")
+		controllerName, _, _ := unstructured.NestedString(pm, "controllerName")
+		if name == gw.Name && ns == gw.Namespace && controllerName == r.controllerName() {
+			conds = getConditions(pm, "conditions")
+			continue
+		}
+		kept = append(kept, p)
+	}
+
+	accepted := metav1.Condition{Type: "Accepted", Status: metav1.ConditionTrue, Reason: rs.AcceptedReason, Message: rs.Message, ObservedGeneration: generation}
+	if !rs.Accepted {
+		accepted.Status = metav1.ConditionFalse
+	}
+	resolved := metav1.Condition{Type: "ResolvedRefs", Status: metav1.ConditionTrue, Reason: rs.RefsReason, ObservedGeneration: generation}
+	if !rs.ResolvedRefs {
+		resolved.Status = metav1.ConditionFalse
+	}
+	meta.SetStatusCondition(&conds, accepted)
+	meta.SetStatusCondition(&conds, resolved)
+
+	parent := map[string]interface{}{
+		"parentRef": map[string]interface{}{
+			"group":     gateway.Group,
+			"kind":      gateway.GatewayGVK.Kind,
+			"namespace": gw.Namespace,
+			"name":      gw.Name,
+		},
+		"controllerName": r.controllerName(),
+	}
+	if err := setConditions(parent, conds, "conditions"); err != nil {
+		return err
+	}
+	kept = append(kept, parent)
+
+	if reflect.DeepEqual(before, runtime.DeepCopyJSONValue(kept)) {
+		return nil
+	}
+
+	if err := unstructured.SetNestedSlice(obj.Object, kept, "status", "parents"); err != nil {
+		return err
+	}
+
+	return r.Status().Update(ctx, obj)
+}
+
+func referencesGateway(route *gateway.HTTPRoute, name, namespace string) bool {
+	for _, ref := range route.Spec.ParentRefs {
+		ns := route.Namespace
+		if ref.Namespace != nil {
+			ns = *ref.Namespace
+		}
+		if ref.Name == name && ns == namespace {
+			return true
+		}
+	}
+
+	return false
+}
+
+func newUnstructured(gvk schema.GroupVersionKind) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	return obj
+}
+
+func getConditions(obj map[string]interface{}, fields ...string) []metav1.Condition {
+	var conds []metav1.Condition
+
+	raw, found, err := unstructured.NestedSlice(obj, fields...)
+	if !found || err != nil {
+		return conds
+	}
+
+	for _, v := range raw {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var c metav1.Condition
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, &c); err == nil {
+			conds = append(conds, c)
+		}
+	}
+
+	return conds
+}
+
+func getListenerConditions(obj map[string]interface{}, name string) []metav1.Condition {
+	listeners, _, _ := unstructured.NestedSlice(obj, "status", "listeners")
+	for _, v := range listeners {
+		m, ok := v.(map[string]interface{})
+		if ok && m["name"] == name {
+			return getConditions(m, "conditions")
+		}
+	}
+
+	return nil
+}
+
+func setConditions(obj map[string]interface{}, conds []metav1.Condition, fields ...string) error {
+	var raw []interface{}
+	for i := range conds {
+		m, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&conds[i])
+		if err != nil {
+			return err
+		}
+		raw = append(raw, m)
+	}
+
+	return unstructured.SetNestedSlice(obj, raw, fields...)
+}
+
+// routeToGateways enqueues the gateways a HTTPRoute is attached to.
+func (r *GatewayReconciler) routeToGateways(_ context.Context, obj client.Object) []reconcile.Request {
+	var reqs []reconcile.Request
+
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return reqs
+	}
+
+	route := new(gateway.HTTPRoute)
+	if err := gateway.FromUnstructured(u.Object, route); err != nil {
+		return reqs
+	}
+
+	for _, ref := range route.Spec.ParentRefs {
+		ns := route.Namespace
+		if ref.Namespace != nil {
+			ns = *ref.Namespace
+		}
+		reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Name: ref.Name, Namespace: ns}})
+	}
+
+	return reqs
+}
+
+// classToGateways enqueues every gateway of a GatewayClass.
+func (r *GatewayReconciler) classToGateways(ctx context.Context, obj client.Object) []reconcile.Request {
+	var reqs []reconcile.Request
+
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(gateway.GatewayGVK.GroupVersion().WithKind(gateway.GatewayGVK.Kind + "List"))
+	if err := r.List(ctx, list); err != nil {
+		return reqs
+	}
+
+	for _, v := range list.Items {
+		className, _, _ := unstructured.NestedString(v.Object, "spec", "gatewayClassName")
+		if className == obj.GetName() {
+			reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Name: v.GetName(), Namespace: v.GetNamespace()}})
+		}
+	}
+
+	return reqs
+}
+
+// SetupWithManager sets up the controller with the Manager, the Gateway API CRDs must be installed.
+func (r *GatewayReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("gateway").
+		For(newUnstructured(gateway.GatewayGVK)).
+		Watches(newUnstructured(gateway.HTTPRouteGVK), handler.EnqueueRequestsFromMapFunc(r.routeToGateways)).
+		Watches(newUnstructured(gateway.GatewayClassGVK), handler.EnqueueRequestsFromMapFunc(r.classToGateways)).
+		Complete(r)
+}
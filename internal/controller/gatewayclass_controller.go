@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/gateway"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// GatewayClassReconciler accepts the GatewayClasses whose spec.controllerName is handled by this instance, their
+// Gateways are rendered by the GatewayReconciler.
+type GatewayClassReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// ControllerName is the spec.controllerName a GatewayClass must carry to be accepted by this instance
+	ControllerName string
+}
+
+func (r *GatewayClassReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	obj := newUnstructured(gateway.GatewayClassGVK)
+	if err := r.Get(ctx, req.NamespacedName, obj); err != nil {
+		klog.Infof("gatewayClass %s not found, maybe has been deleted", req.Name)
+		return ctrl.Result{}, nil
+	}
+
+	if !r.owns(obj) {
+		return ctrl.Result{}, nil
+	}
+
+	conds := getConditions(obj.Object, "status", "conditions")
+	changed := meta.SetStatusCondition(&conds, metav1.Condition{
+		Type:               "Accepted",
+		Status:             metav1.ConditionTrue,
+		Reason:             "Accepted",
+		Message:            "handled by " + r.controllerName(),
+		ObservedGeneration: obj.GetGeneration(),
+	})
+	if !changed {
+		return ctrl.Result{}, nil
+	}
+
+	if err := setConditions(obj.Object, conds, "status", "conditions"); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if err := r.Status().Update(ctx, obj); err != nil {
+		klog.ErrorS(err, fmt.Sprintf("fail to update status of gatewayClass: %s", obj.GetName()))
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// owns reports whether the GatewayClass obj is handled by this controller.
+func (r *GatewayClassReconciler) owns(obj client.Object) bool {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return false
+	}
+
+	name, _, _ := unstructured.NestedString(u.Object, "spec", "controllerName")
+	return name == r.controllerName()
+}
+
+func (r *GatewayClassReconciler) controllerName() string {
+	if r.ControllerName == "" {
+		return DefaultControllerClass
+	}
+
+	return r.ControllerName
+}
+
+// SetupWithManager sets up the controller with the Manager, the Gateway API CRDs must be installed.
+func (r *GatewayClassReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("gatewayclass").
+		For(newUnstructured(gateway.GatewayClassGVK), builder.WithPredicates(predicate.NewPredicateFuncs(r.owns))).
+		Complete(r)
+}
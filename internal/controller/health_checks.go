@@ -0,0 +1,166 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	ingressv1 "github.com/ingoxx/ingress-nginx-kubebuilder/api/v1"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/healthcheck"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/resolver"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/health"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/metrics"
+	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	netv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"slices"
+)
+
+//+kubebuilder:rbac:groups=discovery.k8s.io,resources=endpointslices,verbs=get;list;watch
+
+// healthEventsSize bounds the ingresses waiting to be rendered after one of their endpoints changed state.
+const healthEventsSize = 1024
+
+// syncHealthChecks hands the ready endpoints of the backends of ic to the prober, the endpoints the prober takes out
+// are rendered down and every state change renders ic again.
+func (r *IngressReconciler) syncHealthChecks(ic *ingressv1.Ingress, obj client.Object, rr resolver.Resolver, cfg healthcheck.Config) {
+	key := types.NamespacedName{Name: ic.Name, Namespace: ic.Namespace}.String()
+	metrics.DeleteEndpointHealth(ic.Namespace, ic.Name)
+
+	if cfg.Path == "" {
+		health.Delete(key)
+		return
+	}
+
+	var targets []health.Target
+	for _, rule := range ic.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+
+		for _, p := range rule.HTTP.Paths {
+			port := rr.GetSvcPort(p.Backend)
+			if port == nil {
+				continue
+			}
+
+			addresses, err := rr.GetEndpoints(p.Backend.Service.Name, *port)
+			if err != nil {
+				klog.ErrorS(err, fmt.Sprintf("fail to get the endpoints of service: %s, namespace: %s", p.Backend.Service.Name, ic.Namespace))
+				continue
+			}
+
+			for _, a := range addresses {
+				if t := (health.Target{Service: p.Backend.Service.Name, Address: a}); !slices.Contains(targets, t) {
+					targets = append(targets, t)
+				}
+			}
+		}
+	}
+
+	health.Set(key, cfg.Check(), targets, r.healthNotify(ic, obj))
+
+	for _, t := range targets {
+		metrics.SetEndpointHealth(ic.Namespace, ic.Name, t.Service, t.Address, health.Healthy(key, t.Address))
+	}
+}
+
+// healthNotify reports the endpoints changing state on obj and queues it to be rendered again.
+func (r *IngressReconciler) healthNotify(ic *ingressv1.Ingress, obj client.Object) health.Notify {
+	namespace, name, events := ic.Namespace, ic.Name, r.healthEvents
+
+	return func(t health.Target, healthy bool) {
+		metrics.SetEndpointHealth(namespace, name, t.Service, t.Address, healthy)
+
+		if r.Recorder != nil {
+			if healthy {
+				r.Recorder.Eventf(obj, v1.EventTypeNormal, "BackendHealthy", "endpoint %s of service %s passes its health checks again", t.Address, t.Service)
+			} else {
+				r.Recorder.Eventf(obj, v1.EventTypeWarning, "BackendUnhealthy", "endpoint %s of service %s fails its health checks, it is taken out", t.Address, t.Service)
+			}
+		}
+
+		if events == nil {
+			return
+		}
+
+		select {
+		case events <- event.GenericEvent{Object: obj}:
+		default:
+			klog.Warningf("too many health check events, ingress: %s, namespace: %s is rendered with its next reconcile", name, namespace)
+		}
+	}
+}
+
+// endpointSliceRequests maps an EndpointSlice to the health checked ingress having its Service as a backend.
+func (r *IngressReconciler) endpointSliceRequests(ctx context.Context, obj client.Object, key types.NamespacedName, className string, anns map[string]string, services []string) []reconcile.Request {
+	if !healthcheck.Enabled(anns) || !slices.Contains(services, obj.GetLabels()[discoveryv1.LabelServiceName]) || !r.matchClass(ctx, className, anns) {
+		return nil
+	}
+
+	return []reconcile.Request{{NamespacedName: key}}
+}
+
+func (r *IngressReconciler) ingressesForEndpointSlice(ctx context.Context, obj client.Object) []reconcile.Request {
+	var list ingressv1.IngressList
+	if err := r.List(ctx, &list, client.InNamespace(obj.GetNamespace())); err != nil {
+		klog.ErrorS(err, fmt.Sprintf("fail to list ingresses using endpointslice: %s, namespace: %s", obj.GetName(), obj.GetNamespace()))
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, ing := range list.Items {
+		var services []string
+		for _, rule := range ing.Spec.Rules {
+			if rule.HTTP == nil {
+				continue
+			}
+			for _, p := range rule.HTTP.Paths {
+				if p.Backend.Service != nil {
+					services = append(services, p.Backend.Service.Name)
+				}
+			}
+		}
+
+		key := types.NamespacedName{Name: ing.Name, Namespace: ing.Namespace}
+		requests = append(requests, r.endpointSliceRequests(ctx, obj, key, ing.Spec.IngressClassName, ing.GetAnnotations(), services)...)
+	}
+
+	return requests
+}
+
+func (r *CoreIngressReconciler) ingressesForEndpointSlice(ctx context.Context, obj client.Object) []reconcile.Request {
+	var list netv1.IngressList
+	if err := r.List(ctx, &list, client.InNamespace(obj.GetNamespace())); err != nil {
+		klog.ErrorS(err, fmt.Sprintf("fail to list ingresses using endpointslice: %s, namespace: %s", obj.GetName(), obj.GetNamespace()))
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, ing := range list.Items {
+		var className string
+		if ing.Spec.IngressClassName != nil {
+			className = *ing.Spec.IngressClassName
+		}
+
+		var services []string
+		for _, rule := range ing.Spec.Rules {
+			if rule.HTTP == nil {
+				continue
+			}
+			for _, p := range rule.HTTP.Paths {
+				if p.Backend.Service != nil {
+					services = append(services, p.Backend.Service.Name)
+				}
+			}
+		}
+
+		key := types.NamespacedName{Name: ing.Name, Namespace: ing.Namespace}
+		requests = append(requests, r.endpointSliceRequests(ctx, obj, key, className, ing.GetAnnotations(), services)...)
+	}
+
+	return requests
+}
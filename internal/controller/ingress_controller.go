@@ -21,12 +21,17 @@ import (
 	"fmt"
 	ingressv1 "github.com/ingoxx/ingress-nginx-kubebuilder/api/v1"
 	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/resolver"
 	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/config"
 	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/controller/store"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/health"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/metrics"
 	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/nginx"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/stream"
 	"github.com/ingoxx/ingress-nginx-kubebuilder/pkg/resources"
 	"github.com/ingoxx/ingress-nginx-kubebuilder/pkg/utils/template_nginx"
 	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	netv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -34,11 +39,17 @@ import (
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	"os"
 	"path/filepath"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 	"strings"
 	"time"
 )
@@ -46,10 +57,18 @@ import (
 // IngressReconciler reconciles a Ingress object
 type IngressReconciler struct {
 	client.Client
-	Scheme        *runtime.Scheme
+	Scheme *runtime.Scheme
+	// IngressClass is the ingressClassName (or kubernetes.io/ingress.class annotation value) served by this instance
+	IngressClass string
+	// ControllerClass is the spec.controller value an IngressClass must carry to be served by this instance
+	ControllerClass string
+	// Recorder records the certificate warnings on the reconciled objects
+	Recorder      record.EventRecorder
 	dynamicClient *dynamic.DynamicClient
 	ctx           context.Context
 	ingress       *ingressv1.Ingress
+	// healthEvents queues the ingresses whose endpoints changed health check state
+	healthEvents chan event.GenericEvent
 }
 
 //+kubebuilder:rbac:groups=ingress.nginx.kubebuilder.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
@@ -59,6 +78,7 @@ type IngressReconciler struct {
 //+kubebuilder:rbac:groups=cert-manager.io,resources=issuers,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=ingressclasses,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -87,18 +107,28 @@ func (r *IngressReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 	//	return ctrl.Result{}, nil
 	//}
 
+	result, _, err := r.sync(ctx, ic, ic)
+	return result, err
+}
+
+// sync renders the nginx configuration for ic, it is shared by every reconciler feeding the internal Ingress model.
+// obj is the reconciled object ic was converted from, the certificate events are recorded on it. It reports whether
+// ic is fully served, the result then only schedules the next certificate expiry check.
+func (r *IngressReconciler) sync(ctx context.Context, ic *ingressv1.Ingress, obj client.Object) (ctrl.Result, bool, error) {
 	r.ctx = ctx
 	r.ingress = ic
 
+	// an ingress moved to another class is no longer served, an update of the class brings it back
 	if info := r.checkController(); info != nil {
-		return ctrl.Result{RequeueAfter: time.Second * time.Duration(30)}, nil
+		r.clearConf(types.NamespacedName{Name: ic.Name, Namespace: ic.Namespace})
+		return ctrl.Result{}, false, nil
 	}
 
 	var key client.ObjectKey
 	if ic.Spec.DefaultBackend != nil {
 		key = types.NamespacedName{Name: ic.Spec.DefaultBackend.Service.Name, Namespace: ic.Namespace}
 		if err := r.checkService(key); err != nil {
-			return ctrl.Result{RequeueAfter: time.Second * time.Duration(30)}, nil
+			return ctrl.Result{RequeueAfter: time.Second * time.Duration(30)}, false, nil
 		}
 	}
 
@@ -115,33 +145,60 @@ func (r *IngressReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 	}
 
 	if len(errList) > 0 {
-		return ctrl.Result{RequeueAfter: time.Second * time.Duration(30)}, nil
+		return ctrl.Result{RequeueAfter: time.Second * time.Duration(30)}, false, nil
 	}
 
 	rs := r.GetReconcileInfo()
 	rs.DynamicClientSet = r.dynamicClient
 	rs.IngressInfos = store.NewIngressInfo(rs)
 
-	if err := resources.ReconcileResource(rs); err != nil {
-		return ctrl.Result{RequeueAfter: time.Second * time.Duration(15)}, nil
+	extractor := annotations.NewAnnotationExtractor(rs.IngressInfos)
+	parsed, err := extractor.Extract(ic)
+	if err != nil {
+		klog.ErrorS(err, fmt.Sprintf("fail to parse annotations in ingress: %s, namespace: %s", ic.Name, ic.Namespace))
+		return ctrl.Result{RequeueAfter: time.Second * time.Duration(15)}, false, nil
 	}
 
-	parsed, err := annotations.NewAnnotationExtractor(rs.IngressInfos).Extract(ic)
+	paths, err := extractor.ExtractPaths(ic)
 	if err != nil {
-		klog.ErrorS(err, fmt.Sprintf("fail to parse annotations in ingress: %s, namespace: %s", req.Name, req.Namespace))
-		return ctrl.Result{RequeueAfter: time.Second * time.Duration(15)}, nil
+		klog.ErrorS(err, fmt.Sprintf("fail to parse path overrides in ingress: %s, namespace: %s", ic.Name, ic.Namespace))
+		return ctrl.Result{RequeueAfter: time.Second * time.Duration(15)}, false, nil
+	}
+
+	// the backends of an ssl passthrough ingress terminate tls, the controller holds no certificate for it
+	passthrough := parsed.Passthrough.SSLPassthrough
+	if !passthrough {
+		rs.CertReady, err = resources.ReconcileResource(rs, parsed.CertManager)
+		if err != nil {
+			return ctrl.Result{RequeueAfter: time.Second * time.Duration(15)}, false, nil
+		}
 	}
 
+	r.syncPassthrough(ic, obj, rs.IngressInfos, passthrough)
+	r.syncHealthChecks(ic, obj, rs.IngressInfos, parsed.HealthCheck)
+
 	var ings = annotations.IngressAnnotations{
 		ParsedAnnotations: parsed,
+		PathAnnotations:   paths,
+	}
+
+	n := NewNginxController(rs)
+	if err := n.GenerateConfigure(ings); err != nil {
+		klog.ErrorS(err, fmt.Sprintf("error in ingress: %s, namespace: %s", ic.Name, ic.Namespace))
+		return ctrl.Result{RequeueAfter: time.Second * time.Duration(15)}, false, nil
 	}
 
-	if err := NewNginxController(rs).GenerateConfigure(ings); err != nil {
-		klog.ErrorS(err, fmt.Sprintf("error in ingress: %s, namespace: %s", req.Name, req.Namespace))
-		return ctrl.Result{RequeueAfter: time.Second * time.Duration(15)}, nil
+	expiry := r.reportCertificates(ctx, ic, obj, n.Certificates(), n.RejectedCertificates())
+	r.reportModSecurity(ctx, ic, obj, ings)
+
+	// the servers are rendered without tls until cert-manager issued the certificate, check again later
+	if !passthrough && resources.ManagesCertificate(ic, parsed.CertManager) && !rs.CertReady {
+		klog.Infof("certificate of ingress: %s, namespace: %s is not ready yet, https is not served", ic.Name, ic.Namespace)
+		return ctrl.Result{RequeueAfter: time.Second * time.Duration(15)}, false, nil
 	}
 
-	return ctrl.Result{}, nil
+	// an unchanged ingress is checked again to warn about its certificates before they expire
+	return ctrl.Result{RequeueAfter: expiry}, true, nil
 }
 
 func (r *IngressReconciler) GetReconcileInfo() *store.IngressReconciler {
@@ -191,31 +248,164 @@ func (r *IngressReconciler) createDynamicClientSet() *dynamic.DynamicClient {
 }
 
 func (r *IngressReconciler) checkController() error {
-	ic := new(netv1.IngressClass)
-	getAnnotations := r.ingress.GetAnnotations()
-	if r.ingress.Spec.IngressClassName == "" && getAnnotations[nginxAnnotationKey] == "" {
+	if r.ingress.Spec.IngressClassName == "" && r.ingress.GetAnnotations()[nginxAnnotationKey] == "" {
 		klog.Infoln("the current controller can be used by adding ingressClass or annotating specified values")
 		return fmt.Errorf("select available ingress nginx controller")
 	}
 
-	if r.ingress.Annotations[nginxAnnotationKey] == nginxAnnotationVal {
-		return nil
+	if !r.matchClass(r.ctx, r.ingress.Spec.IngressClassName, r.ingress.GetAnnotations()) {
+		klog.Infoln("neither ingressClass nor the ingress.class annotation matches the current controller")
+		return fmt.Errorf("pls select available ingress nginx controller")
 	}
 
-	key := types.NamespacedName{Name: r.ingress.Spec.IngressClassName, Namespace: r.ingress.Namespace}
-	if err := r.Get(r.ctx, key, ic); err != nil {
-		return err
+	return nil
+}
+
+// matchClass reports whether an object with the given ingressClassName and annotations is served by this instance.
+// The ingress.class annotation takes precedence; otherwise the IngressClass must have the expected name and
+// its spec.controller must match, so several instances with different classes never reconcile each other's objects.
+func (r *IngressReconciler) matchClass(ctx context.Context, className string, anns map[string]string) bool {
+	if val, ok := anns[nginxAnnotationKey]; ok && val != "" {
+		return val == r.ingressClass()
 	}
 
-	if ic.Spec.Controller != controller {
-		klog.Infoln("neither ingressClass nor nginxAnnotationVal value matches the current controller")
-		return fmt.Errorf("pls select available ingress nginx controller")
+	if className == "" || className != r.ingressClass() {
+		return false
 	}
 
-	return nil
+	ic := new(netv1.IngressClass)
+	if err := r.Get(ctx, types.NamespacedName{Name: className}, ic); err != nil {
+		klog.ErrorS(err, fmt.Sprintf("fail to get ingressClass: %s", className))
+		return false
+	}
+
+	return ic.Spec.Controller == r.controllerClass()
+}
+
+func (r *IngressReconciler) ingressClass() string {
+	if r.IngressClass == "" {
+		return DefaultIngressClass
+	}
+
+	return r.IngressClass
+}
+
+func (r *IngressReconciler) controllerClass() string {
+	if r.ControllerClass == "" {
+		return DefaultControllerClass
+	}
+
+	return r.ControllerClass
+}
+
+// classPredicate drops events for ingresses that belong to another controller instance.
+func (r *IngressReconciler) classPredicate() predicate.Funcs {
+	return newClassPredicate(func(obj client.Object) bool {
+		ing, ok := obj.(*ingressv1.Ingress)
+		if !ok {
+			return false
+		}
+
+		return r.matchClass(context.Background(), ing.Spec.IngressClassName, ing.GetAnnotations())
+	})
+}
+
+// newClassPredicate filters events with match.
+// Updates are kept if either the old or the new object matches, so moving an ingress away still cleans up its conf.
+func newClassPredicate(match func(obj client.Object) bool) predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return match(e.Object)
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return match(e.ObjectOld) || match(e.ObjectNew)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return match(e.Object)
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return match(e.Object)
+		},
+	}
+}
+
+// syncPassthrough registers the hosts of ic with the ssl passthrough listener, each host is routed to the backend of
+// its first path. The tls stream can't be routed by path, the other paths are reported on obj.
+func (r *IngressReconciler) syncPassthrough(ic *ingressv1.Ingress, obj client.Object, rr resolver.Resolver, enabled bool) {
+	key := types.NamespacedName{Name: ic.Name, Namespace: ic.Namespace}.String()
+	if !enabled {
+		r.deletePassthrough(key)
+		return
+	}
+
+	if !config.SSLPassthrough {
+		klog.Warningf("ingress: %s, namespace: %s requests ssl passthrough, but it is not enabled in the controller", ic.Name, ic.Namespace)
+		return
+	}
+
+	var listeners []stream.Listener
+	for i, rule := range ic.Spec.Rules {
+		if rule.Host == "" || rule.HTTP == nil || len(rule.HTTP.Paths) == 0 || rule.HTTP.Paths[0].Backend.Service == nil {
+			continue
+		}
+
+		if len(rule.HTTP.Paths) > 1 {
+			klog.Warningf("ssl passthrough of host: %s in ingress: %s, namespace: %s only routes path: %s", rule.Host, ic.Name, ic.Namespace, rule.HTTP.Paths[0].Path)
+			r.event(obj, "PassthroughPathsIgnored", "ssl passthrough routes host %s by sni only, its paths after %s are ignored", rule.Host, rule.HTTP.Paths[0].Path)
+		}
+
+		backend := rule.HTTP.Paths[0].Backend
+		port := rr.GetSvcPort(backend)
+		if port == nil {
+			klog.Warningf("no port of service: %s matches the ssl passthrough backend of host: %s", backend.Service.Name, rule.Host)
+			continue
+		}
+
+		listeners = append(listeners, stream.Listener{
+			Name:        fmt.Sprintf("%s-%s-%d", ic.Name, ic.Namespace, i),
+			Port:        config.HTTPSPort,
+			Passthrough: true,
+			Host:        rule.Host,
+			Servers: []ingressv1.UpstreamServer{{
+				Address: fmt.Sprintf("%s.%s.svc:%d", backend.Service.Name, ic.Namespace, *port),
+			}},
+		})
+	}
+
+	stream.SetPassthrough(key, listeners)
+	rejected, err := stream.Sync()
+	if err != nil {
+		klog.ErrorS(err, fmt.Sprintf("fail to render the ssl passthrough hosts of ingress: %s, namespace: %s", ic.Name, ic.Namespace))
+		return
+	}
+
+	for _, l := range listeners {
+		if err, ok := rejected[l.Name]; ok {
+			klog.ErrorS(err, fmt.Sprintf("ssl passthrough of host: %s is ignored in ingress: %s, namespace: %s", l.Host, ic.Name, ic.Namespace))
+		}
+	}
+}
+
+func (r *IngressReconciler) deletePassthrough(key string) {
+	if !stream.DeletePassthrough(key) {
+		return
+	}
+
+	if _, err := stream.Sync(); err != nil {
+		klog.ErrorS(err, fmt.Sprintf("fail to remove the ssl passthrough hosts of ingress: %s", key))
+	}
 }
 
 func (r *IngressReconciler) clearConf(key client.ObjectKey) {
+	r.deletePassthrough(key.String())
+	health.Delete(key.String())
+	metrics.DeleteIngress(key.Namespace, key.Name)
+
+	if acme, _ := filepath.Glob(config.AcmeConf("*", key.Name, key.Namespace) + ".conf"); len(acme) > 0 {
+		nginx.CleanConf(acme...)
+		return
+	}
+
 	conf := filepath.Join(config.ConfDir, key.Name+"-"+key.Namespace+".conf")
 	if _, err := os.Stat(conf); err != nil {
 		defaultConf := strings.Split(config.MainConf, ".")
@@ -235,11 +425,45 @@ func (r *IngressReconciler) clearConf(key client.ObjectKey) {
 	nginx.CleanConf(conf)
 }
 
+// prepareConf writes the default certificate, the main conf and the stream conf before nginx starts, the listeners
+// of the https servers depend on whether ssl passthrough is enabled.
+func (r *IngressReconciler) prepareConf() {
+	if err := ensureDefaultCertificate(); err != nil {
+		klog.ErrorS(err, fmt.Sprintf("fail to write the default ssl certificate in %s", config.SslPath))
+	}
+
+	defaultConf := strings.Split(config.MainConf, ".")
+	pr := &template_nginx.RenderTemplate{
+		GenerateName:       defaultConf[0],
+		RenderTemplateName: config.DefaultTmpl,
+		MainTemplateName:   config.NginxTmpl,
+	}
+
+	if err := NewConfHandler().WriteDefaultConf(pr); err != nil {
+		klog.ErrorS(err, fmt.Sprintf("fail to write %s", config.MainConf))
+	}
+
+	if err := stream.Prepare(); err != nil {
+		klog.ErrorS(err, fmt.Sprintf("fail to write the stream conf in %s", config.StreamConfDir))
+	}
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *IngressReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.prepareConf()
 	go nginx.Start()
 	r.dynamicClient = r.createDynamicClientSet()
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&ingressv1.Ingress{}).
-		Complete(r)
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&ingressv1.Ingress{}, builder.WithPredicates(r.classPredicate())).
+		Watches(&v1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.ingressesForSecret)).
+		Watches(&ingressv1.SecretGrant{}, handler.EnqueueRequestsFromMapFunc(r.ingressesForSecret)).
+		Watches(&v1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.ingressesForConfigMap))
+
+	if config.HealthChecks {
+		r.healthEvents = make(chan event.GenericEvent, healthEventsSize)
+		b = b.WatchesRawSource(source.Channel(r.healthEvents, &handler.EnqueueRequestForObject{})).
+			Watches(&discoveryv1.EndpointSlice{}, handler.EnqueueRequestsFromMapFunc(r.ingressesForEndpointSlice))
+	}
+
+	return b.Complete(r)
 }
@@ -0,0 +1,98 @@
+package controller
+
+import (
+	ingressv1 "github.com/ingoxx/ingress-nginx-kubebuilder/api/v1"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations"
+	"k8s.io/klog/v2"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// pathType returns the type of p, ImplementationSpecific when it is not set.
+func pathType(p ingressv1.HTTPIngressPath) ingressv1.PathType {
+	if p.PathType == nil || *p.PathType == "" {
+		return ingressv1.PathTypeImplementationSpecific
+	}
+
+	return *p.PathType
+}
+
+// usesRegex reports whether the ImplementationSpecific paths of an ingress are regular expressions.
+func usesRegex(anns *annotations.Ingress) bool {
+	return anns.Rewrite.EnableRegex || anns.Rewrite.RewriteTarget != ""
+}
+
+// locationPaths returns the locations rendering p. A Prefix path is split into an exact location for the path itself
+// and a prefix location ending with / so that /foo doesn't match /foobar.
+func locationPaths(p ingressv1.HTTPIngressPath, anns *annotations.Ingress) []string {
+	switch pathType(p) {
+	case ingressv1.PathTypeExact:
+		return []string{"= " + p.Path}
+	case ingressv1.PathTypePrefix:
+		path := strings.TrimRight(p.Path, "/")
+		if path == "" {
+			return []string{"/"}
+		}
+		return []string{"= " + path, path + "/"}
+	}
+
+	if usesRegex(anns) {
+		return []string{"~ ^" + p.Path}
+	}
+
+	return []string{p.Path}
+}
+
+// exactFirst moves the Exact paths first so that they win over the exact location of a Prefix path.
+func exactFirst(paths []ingressv1.HTTPIngressPath) []ingressv1.HTTPIngressPath {
+	list := slices.Clone(paths)
+	sort.SliceStable(list, func(i, j int) bool {
+		return pathType(list[i]) == ingressv1.PathTypeExact && pathType(list[j]) != ingressv1.PathTypeExact
+	})
+
+	return list
+}
+
+// locationOrder returns whether the location is a regex and the path it matches.
+func locationOrder(location string) (bool, string) {
+	if path, ok := strings.CutPrefix(location, "~ "); ok {
+		return true, path
+	}
+
+	return false, strings.TrimPrefix(location, "= ")
+}
+
+// sortLocations orders the locations of a server so that the configuration is stable: the exact and prefix
+// locations longest first, then the regex ones in the order of the ingress since nginx tries them in that order.
+// A location rendered twice, e.g. an Exact path and the exact part of a Prefix path, is kept once, see exactFirst.
+func sortLocations(backends []*ingressv1.Backend, name, namespace string) []*ingressv1.Backend {
+	var list []*ingressv1.Backend
+	seen := make(map[string]bool)
+	for _, b := range backends {
+		if seen[b.Path] {
+			klog.Warningf("location: %s of ingress: %s, namespace: %s is defined more than once, keeping the first", b.Path, name, namespace)
+			continue
+		}
+		seen[b.Path] = true
+		list = append(list, b)
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		ri, pi := locationOrder(list[i].Path)
+		rj, pj := locationOrder(list[j].Path)
+		if ri || rj {
+			return !ri && rj
+		}
+		if len(pi) != len(pj) {
+			return len(pi) > len(pj)
+		}
+		if pi != pj {
+			return pi < pj
+		}
+
+		return strings.HasPrefix(list[i].Path, "= ") && !strings.HasPrefix(list[j].Path, "= ")
+	})
+
+	return list
+}
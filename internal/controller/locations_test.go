@@ -0,0 +1,177 @@
+package controller
+
+import (
+	"fmt"
+	ingressv1 "github.com/ingoxx/ingress-nginx-kubebuilder/api/v1"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/rewrite"
+	"slices"
+	"testing"
+)
+
+func httpPath(path string, pathType ingressv1.PathType, service string) ingressv1.HTTPIngressPath {
+	return ingressv1.HTTPIngressPath{
+		Path:     path,
+		PathType: &pathType,
+		Backend:  ingressv1.IngressBackend{Service: &ingressv1.IngressServiceBackend{Name: service}},
+	}
+}
+
+func TestLocationPaths(t *testing.T) {
+	regex := &annotations.Ingress{Rewrite: rewrite.Config{EnableRegex: true}}
+	tests := []struct {
+		name string
+		path ingressv1.HTTPIngressPath
+		anns *annotations.Ingress
+		want []string
+	}{
+		{name: "exact", path: httpPath("/foo", ingressv1.PathTypeExact, "a"), want: []string{"= /foo"}},
+		{name: "prefix", path: httpPath("/foo", ingressv1.PathTypePrefix, "a"), want: []string{"= /foo", "/foo/"}},
+		{name: "prefix with trailing slash", path: httpPath("/foo/", ingressv1.PathTypePrefix, "a"), want: []string{"= /foo", "/foo/"}},
+		{name: "root prefix", path: httpPath("/", ingressv1.PathTypePrefix, "a"), want: []string{"/"}},
+		{name: "implementation specific", path: httpPath("/foo", ingressv1.PathTypeImplementationSpecific, "a"), want: []string{"/foo"}},
+		{name: "unset type", path: ingressv1.HTTPIngressPath{Path: "/foo"}, want: []string{"/foo"}},
+		{name: "regex", path: httpPath("/foo/.*", ingressv1.PathTypeImplementationSpecific, "a"), anns: regex, want: []string{"~ ^/foo/.*"}},
+		{name: "prefix ignores regex", path: httpPath("/foo", ingressv1.PathTypePrefix, "a"), anns: regex, want: []string{"= /foo", "/foo/"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			anns := tt.anns
+			if anns == nil {
+				anns = &annotations.Ingress{}
+			}
+			if got := locationPaths(tt.path, anns); !slices.Equal(got, tt.want) {
+				t.Errorf("locationPaths(%s) = %v, want %v", tt.path.Path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExactFirst(t *testing.T) {
+	paths := []ingressv1.HTTPIngressPath{
+		httpPath("/foo", ingressv1.PathTypePrefix, "prefix"),
+		httpPath("/bar", ingressv1.PathTypeImplementationSpecific, "specific"),
+		httpPath("/foo", ingressv1.PathTypeExact, "exact"),
+		httpPath("/baz", ingressv1.PathTypeExact, "exact2"),
+	}
+
+	var got []string
+	for _, p := range exactFirst(paths) {
+		got = append(got, p.Backend.Service.Name)
+	}
+
+	if want := []string{"exact", "exact2", "prefix", "specific"}; !slices.Equal(got, want) {
+		t.Errorf("exactFirst() = %v, want %v", got, want)
+	}
+	if paths[0].Backend.Service.Name != "prefix" {
+		t.Errorf("exactFirst() reordered its argument")
+	}
+}
+
+func TestSortLocations(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths []ingressv1.HTTPIngressPath
+		anns  *annotations.Ingress
+		want  []string
+	}{
+		{
+			name: "exact wins over the exact location of a prefix",
+			paths: []ingressv1.HTTPIngressPath{
+				httpPath("/foo", ingressv1.PathTypePrefix, "prefix"),
+				httpPath("/foo", ingressv1.PathTypeExact, "exact"),
+			},
+			want: []string{"/foo/ prefix", "= /foo exact"},
+		},
+		{
+			name: "foo doesn't shadow foobar",
+			paths: []ingressv1.HTTPIngressPath{
+				httpPath("/foo", ingressv1.PathTypePrefix, "foo"),
+				httpPath("/foobar", ingressv1.PathTypePrefix, "foobar"),
+			},
+			want: []string{"/foobar/ foobar", "= /foobar foobar", "/foo/ foo", "= /foo foo"},
+		},
+		{
+			name: "exact before prefix of the same path",
+			paths: []ingressv1.HTTPIngressPath{
+				httpPath("/foo/", ingressv1.PathTypeImplementationSpecific, "specific"),
+				httpPath("/foo/", ingressv1.PathTypeExact, "exact"),
+			},
+			want: []string{"= /foo/ exact", "/foo/ specific"},
+		},
+		{
+			name: "regex last in the order of the ingress",
+			paths: []ingressv1.HTTPIngressPath{
+				httpPath("/b.*", ingressv1.PathTypeImplementationSpecific, "b"),
+				httpPath("/", ingressv1.PathTypePrefix, "root"),
+				httpPath("/a.*", ingressv1.PathTypeImplementationSpecific, "a"),
+				httpPath("/long", ingressv1.PathTypeExact, "long"),
+			},
+			anns: &annotations.Ingress{Rewrite: rewrite.Config{EnableRegex: true}},
+			want: []string{"= /long long", "/ root", "~ ^/b.* b", "~ ^/a.* a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			anns := tt.anns
+			if anns == nil {
+				anns = &annotations.Ingress{}
+			}
+
+			var backends []*ingressv1.Backend
+			for _, p := range exactFirst(tt.paths) {
+				for _, l := range locationPaths(p, anns) {
+					backends = append(backends, &ingressv1.Backend{Name: p.Backend.Service.Name, Path: l})
+				}
+			}
+
+			var got []string
+			for _, b := range sortLocations(backends, "test", "default") {
+				got = append(got, b.Path+" "+b.Name)
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("sortLocations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpstreams(t *testing.T) {
+	a := &ingressv1.Server{HostName: "a.example.com", Paths: []*ingressv1.Backend{
+		{Name: "svc", Port: 80, Path: "/"},
+		{Name: "svc", Port: 80, Path: "/x/"},
+		{Name: "other", Port: 80, Path: "/y/"},
+	}}
+	b := &ingressv1.Server{HostName: "b.example.com", Paths: []*ingressv1.Backend{
+		{Name: "svc", Port: 80, Path: "/"},
+		{Name: "svc", Port: 8080, Path: "/z/"},
+	}}
+	cfg := &ingressv1.Configuration{Servers: []*ingressv1.Server{a, b}}
+
+	tests := []struct {
+		name   string
+		server *ingressv1.Server
+		want   []string
+	}{
+		{name: "first server", server: a, want: []string{"svc-80", "other-80"}},
+		{name: "second server", server: b, want: []string{"svc-8080"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &configure{Cfg: cfg, Server: tt.server}
+
+			var got []string
+			for _, u := range c.Upstreams() {
+				got = append(got, fmt.Sprintf("%s-%d", u.Name, u.Port))
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Upstreams() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	ingressv1 "github.com/ingoxx/ingress-nginx-kubebuilder/api/v1"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/nginx"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"slices"
+)
+
+// reportModSecurity reports whether the WAF of an ingress with enable-modsecurity, on the ingress or a path override,
+// is rendered, its locations are served without it when nginx can't load the ModSecurity module.
+func (r *IngressReconciler) reportModSecurity(ctx context.Context, ic *ingressv1.Ingress, obj client.Object, ings annotations.IngressAnnotations) {
+	enabled := slices.ContainsFunc(ings.All(), func(a *annotations.Ingress) bool { return a.ModSecurity.Enable })
+	available := nginx.ModSecurityAvailable()
+	if enabled && !available {
+		klog.Warningf("ModSecurity module isn't available, ingress: %s, namespace: %s is served without the WAF", ic.Name, ic.Namespace)
+		r.event(obj, "ModSecurityUnavailable", "nginx isn't built with the ModSecurity module and --modsecurity-module isn't set, the WAF is not rendered")
+	}
+
+	ing, ok := obj.(*ingressv1.Ingress)
+	if !ok {
+		return
+	}
+
+	cond := metav1.Condition{
+		Type:               ingressv1.IngressModSecurityReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Rendered",
+		Message:            "the ModSecurity WAF is rendered into the locations",
+		ObservedGeneration: ing.Generation,
+	}
+
+	if !available {
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = "ModuleNotAvailable"
+		cond.Message = "nginx isn't built with the ModSecurity module, the WAF is not rendered"
+	}
+
+	if err := r.updateCondition(ctx, ing, cond, enabled); err != nil {
+		klog.ErrorS(err, fmt.Sprintf("fail to update status of ingress: %s, namespace: %s", ing.Name, ing.Namespace))
+	}
+}
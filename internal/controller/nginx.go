@@ -3,19 +3,28 @@ package controller
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	ingressv1 "github.com/ingoxx/ingress-nginx-kubebuilder/api/v1"
 	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/modsecurity"
 	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/parser"
 	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/resolver"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/weight"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/certstore"
 	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/config"
 	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/controller/store"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/health"
 	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/nginx"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/pkg/resources"
+	cert "github.com/ingoxx/ingress-nginx-kubebuilder/pkg/utils/cert"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/pkg/utils/template_nginx"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog/v2"
 	"os"
 	"path/filepath"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"slices"
 	"strings"
 	"sync"
 	"text/template"
@@ -29,24 +38,148 @@ type configure struct {
 	TmplName    string
 	MainTmpl    string
 	ConfName    string
+	SSLListen   []string
+	HTTPListen  []string
+	// Certs are the certificates of the hosts of Cfg, those of the rendered servers.
+	Certs map[string]*cert.Info
 }
 
+// AcmeInclude returns the include of the acme challenge locations of the server being rendered,
+// wildcard hosts can't be validated over http01 so they have none.
+func (c *configure) AcmeInclude() string {
+	if c.Server == nil || c.Server.HostName == "" || strings.HasPrefix(c.Server.HostName, "*") {
+		return ""
+	}
+
+	return config.AcmeConf(c.Server.HostName, "*", "*") + ".conf"
+}
+
+// WWWAlias returns the host the from-to-www-redirect annotation redirects to the server being rendered,
+// empty when it is not set or when the ingress serves that host itself.
+func (c *configure) WWWAlias() string {
+	if c.Server == nil || !c.Annotations.Redirect.FromToWWW || c.Server.HostName == "" || strings.HasPrefix(c.Server.HostName, "*") {
+		return ""
+	}
+
+	alias := "www." + c.Server.HostName
+	if h, ok := strings.CutPrefix(c.Server.HostName, "www."); ok && strings.Contains(h, ".") {
+		alias = h
+	}
+
+	for _, s := range c.Cfg.Servers {
+		if s.HostName == alias {
+			return ""
+		}
+	}
+
+	return alias
+}
+
+// WWWAliasTLS reports whether the certificate of the server being rendered covers alias, the redirect of the alias is
+// only served over https when it does.
+func (c *configure) WWWAliasTLS(alias string) bool {
+	info := c.Certs[c.Server.HostName]
+	return info != nil && info.Covers(alias)
+}
+
+// MirrorUpstream returns the upstream the mirror-target annotation copies the requests of the server being rendered
+// to, named after the host as the file of an ingress holds all of its servers, empty when it is not set.
+func (c *configure) MirrorUpstream() string {
+	if c.Server == nil || c.Annotations.Mirror.Target == "" {
+		return ""
+	}
+
+	host := strings.ReplaceAll(c.Server.HostName, "*", "_")
+	if host == "" {
+		host = "_"
+	}
+
+	return fmt.Sprintf("mirror-%s-%s-%s", host, c.Server.Name, c.Server.NameSpace)
+}
+
+// GeoVariable returns the variable set by the map of the geo-allow-countries and geo-deny-countries annotations
+// when the client of the server being rendered is refused.
+func (c *configure) GeoVariable() string {
+	if c.Server == nil || !c.Annotations.GeoIP.Enabled() {
+		return ""
+	}
+
+	name := fmt.Sprintf("geo_denied_%s_%s_%s", c.Server.HostName, c.Server.Name, c.Server.NameSpace)
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// ModSecurity reports whether the WAF of cfg, the enable-modsecurity annotation of a location, is rendered, it is
+// left out when nginx can't load the module so that the configuration still passes nginx -t.
+func (c *configure) ModSecurity(cfg modsecurity.Config) bool {
+	return cfg.Enable && nginx.ModSecurityAvailable()
+}
+
+// Upstreams returns the backends of the server being rendered with an upstream of their own, the locations of the
+// paths to the same Service port share it. The servers of a file share them too, an upstream is rendered with the
+// first server routing to it.
+func (c *configure) Upstreams() []*ingressv1.Backend {
+	if c.Server == nil {
+		return nil
+	}
+
+	var rendered, list []*ingressv1.Backend
+	for _, s := range c.Cfg.Servers {
+		for _, b := range s.Paths {
+			if slices.ContainsFunc(rendered, func(u *ingressv1.Backend) bool { return u.Name == b.Name && u.Port == b.Port }) {
+				continue
+			}
+			rendered = append(rendered, b)
+			if s == c.Server {
+				list = append(list, b)
+			}
+		}
+
+		if s == c.Server {
+			break
+		}
+	}
+
+	return list
+}
+
+// WeightUpstreams returns the upstreams of the weight annotations, they belong to the ingress so they are only
+// rendered with the first server of the file.
+func (c *configure) WeightUpstreams() []weight.UpstreamList {
+	if c.Server == nil || len(c.Cfg.Servers) == 0 || c.Cfg.Servers[0] != c.Server {
+		return nil
+	}
+
+	return c.Annotations.Weight.Up
+}
+
+// errRefNotPermitted marks a tls Secret of another namespace that no SecretGrant lets the ingress use.
+var errRefNotPermitted = errors.New("reference not permitted")
+
 type NginxController struct {
-	client  client.Client
-	ctx     context.Context
-	rr      resolver.Resolver
-	mux     *sync.RWMutex
-	ingress *ingressv1.Ingress
+	client    client.Client
+	ctx       context.Context
+	rr        resolver.Resolver
+	mux       *sync.RWMutex
+	ingress   *ingressv1.Ingress
+	certReady bool
+	certs     map[string]*cert.Info
+	rejected  map[string]error
 }
 
 func NewNginxController(store store.Storer) *NginxController {
 	st := store.ReconcilerInfo()
 	n := &NginxController{
-		client:  st.Client,
-		ctx:     st.Context,
-		rr:      st.IngressInfos,
-		ingress: st.Ingress,
-		mux:     new(sync.RWMutex),
+		client:    st.Client,
+		ctx:       st.Context,
+		rr:        st.IngressInfos,
+		ingress:   st.Ingress,
+		mux:       new(sync.RWMutex),
+		certReady: st.CertReady,
 	}
 
 	return n
@@ -79,7 +212,7 @@ func (n *NginxController) generateConf(name string, b []byte) error {
 		return err
 	}
 
-	if err := os.WriteFile(filepath.Join("/etc/nginx", filepath.Base(testConf)), b, 0644); err != nil {
+	if err := os.WriteFile(filepath.Join(filepath.Dir(config.MainConf), filepath.Base(testConf)), b, 0644); err != nil {
 		klog.ErrorS(err, fmt.Sprintf("an error occurred while writing the generated content to %s", testConf))
 		//return err
 	}
@@ -108,6 +241,8 @@ func (n *NginxController) generateConfigureBytes(cfg *configure) error {
 	}
 
 	if cfg != nil {
+		cfg.SSLListen = config.SSLListen()
+		cfg.HTTPListen = config.HTTPListen()
 		for _, v := range cfg.Cfg.Servers {
 			cfg.Server = v
 			if err := n.generateServerBytes(cfg); err != nil {
@@ -124,7 +259,7 @@ func (n *NginxController) generateConfigureBytes(cfg *configure) error {
 	}
 
 	var tpl bytes.Buffer
-	if err = mainTmpl.Execute(&tpl, nil); err != nil {
+	if err = mainTmpl.Execute(&tpl, config.NewMain()); err != nil {
 		return err
 	}
 
@@ -139,6 +274,10 @@ func (n *NginxController) GenerateConfigure(ingress annotations.IngressAnnotatio
 	n.mux.Lock()
 	defer n.mux.Unlock()
 
+	if resources.IsAcmeSolver(n.ingress) {
+		return n.generateAcmeTemplate(ingress)
+	}
+
 	if len(n.ingress.Spec.Rules) > 0 {
 		if err := n.generateBackendTemplate(ingress); err != nil {
 			return err
@@ -166,6 +305,7 @@ func (n *NginxController) generateBackendTemplate(ingress annotations.IngressAnn
 		TmplName:    config.ServerTmpl,
 		MainTmpl:    config.MainServerTmpl,
 		ConfName:    filepath.Join(config.ConfDir, n.ingress.Name+"-"+n.ingress.Namespace),
+		Certs:       n.certs,
 	}
 
 	if err := n.generateConfigureBytes(cfg); err != nil {
@@ -181,6 +321,58 @@ func (n *NginxController) generateBackendTemplate(ingress annotations.IngressAnn
 	return nil
 }
 
+// generateAcmeTemplate writes the challenge locations of an acme solver ingress, the server of their host includes
+// them instead of a server of their own so the challenges get past its redirects.
+func (n *NginxController) generateAcmeTemplate(ingress annotations.IngressAnnotations) error {
+	serversCfg, err := n.getBackendConfigure(ingress)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range serversCfg.Servers {
+		var paths []*ingressv1.Backend
+		for _, b := range s.Paths {
+			if !strings.HasPrefix(b.TargetPath, config.AcmeLocationPath) {
+				klog.Warningf("path: %s of acme solver ingress: %s, namespace: %s is not a challenge, skipping", b.TargetPath, n.ingress.Name, n.ingress.Namespace)
+				continue
+			}
+			if slices.ContainsFunc(paths, func(p *ingressv1.Backend) bool { return p.TargetPath == b.TargetPath }) {
+				continue
+			}
+			paths = append(paths, b)
+		}
+
+		if s.HostName == "" || len(paths) == 0 {
+			continue
+		}
+
+		name := config.AcmeConf(s.HostName, n.ingress.Name, n.ingress.Namespace)
+		pr := &template_nginx.RenderTemplate{
+			GenerateName:       name,
+			RenderTemplateName: config.AcmeTmpl,
+			MainTemplateName:   config.MainServerTmpl,
+		}
+
+		var data = struct {
+			Paths []*ingressv1.Backend
+		}{
+			Paths: paths,
+		}
+
+		if err := pr.Render(data); err != nil {
+			return err
+		}
+
+		if err := nginx.Reload(name); err != nil {
+			return err
+		}
+
+		klog.Infof("update acme challenges of host: %s from ingress: %s, namespace: %s", s.HostName, n.ingress.Name, n.ingress.Namespace)
+	}
+
+	return nil
+}
+
 func (n *NginxController) generateDefaultBackendTemplate(ingress annotations.IngressAnnotations) error {
 	defaultCfg, err := n.getDefaultBackendConfigure(ingress)
 	if err != nil {
@@ -251,9 +443,12 @@ func (n *NginxController) getBackendConfigure(ingCfg annotations.IngressAnnotati
 	var rules = n.ingress.Spec.Rules
 	var servers = make([]*ingressv1.Server, len(rules))
 
-	tls, err := n.generateTlsFile()
-	if err != nil {
-		klog.Warningf(fmt.Sprintf("failed to generate certificate and will not be able to use https"))
+	var err error
+	var tls = make(map[string]ingressv1.SSLCert)
+	if !ingCfg.ParsedAnnotations.Passthrough.SSLPassthrough {
+		if tls, err = n.generateTlsFile(); err != nil {
+			klog.Warningf(fmt.Sprintf("failed to generate certificate and will not be able to use https"))
+		}
 	}
 
 	for k, v := range rules {
@@ -269,12 +464,13 @@ func (n *NginxController) getBackendConfigure(ingCfg annotations.IngressAnnotati
 				return nil, fmt.Errorf("upstream name not found")
 			}
 		} else {
-			ingressPaths = v.HTTP.Paths
+			ingressPaths = exactFirst(v.HTTP.Paths)
 		}
 
-		var backend = make([]*ingressv1.Backend, backendLen)
-		for bk, p := range ingressPaths {
-			if err = n.checkIngressContent(&p, ingCfg.ParsedAnnotations); err != nil {
+		var backend = make([]*ingressv1.Backend, 0, backendLen)
+		for _, p := range ingressPaths {
+			anns := ingCfg.ForPath(v.Host, p.Path)
+			if err = n.checkIngressContent(&p, anns); err != nil {
 				return nil, err
 			}
 
@@ -289,26 +485,34 @@ func (n *NginxController) getBackendConfigure(ingCfg annotations.IngressAnnotati
 				return nil, fmt.Errorf("svc port not exists")
 			}
 
-			b := &ingressv1.Backend{
-				IngName:        n.ingress.Name,
-				Name:           svc.Name,
-				NameSpace:      svc.Namespace,
-				Path:           n.formatPath(p.Path, ingCfg),
-				TargetPath:     p.Path,
-				Port:           *backendPort,
-				ServiceBackend: p.Backend.Service,
-				Annotations:    ingCfg.ParsedAnnotations,
-				UpstreamName:   UpStreamName,
+			var endpoints []ingressv1.Endpoint
+			if ingCfg.ParsedAnnotations.HealthCheck.Path != "" {
+				if endpoints, err = n.healthCheckedEndpoints(svc.Name, *backendPort); err != nil {
+					return nil, err
+				}
 			}
 
-			backend = append(backend[:bk], b)
+			for _, location := range locationPaths(p, anns) {
+				backend = append(backend, &ingressv1.Backend{
+					IngName:        n.ingress.Name,
+					Name:           svc.Name,
+					NameSpace:      svc.Namespace,
+					Path:           location,
+					TargetPath:     p.Path,
+					Port:           *backendPort,
+					ServiceBackend: p.Backend.Service,
+					Annotations:    anns,
+					UpstreamName:   UpStreamName,
+					Endpoints:      endpoints,
+				})
+			}
 		}
 
 		s := &ingressv1.Server{
 			Name:      n.ingress.Name,
 			NameSpace: n.ingress.Namespace,
 			HostName:  v.Host,
-			Paths:     backend,
+			Paths:     sortLocations(backend, n.ingress.Name, n.ingress.Namespace),
 			Tls:       tls[v.Host],
 		}
 
@@ -318,98 +522,217 @@ func (n *NginxController) getBackendConfigure(ingCfg annotations.IngressAnnotati
 	return &ingressv1.Configuration{Servers: servers}, nil
 }
 
+// healthCheckedEndpoints returns the ready endpoints of the Service name, those failing their health checks are down.
+func (n *NginxController) healthCheckedEndpoints(name string, port int32) ([]ingressv1.Endpoint, error) {
+	addresses, err := n.rr.GetEndpoints(name, port)
+	if err != nil {
+		return nil, err
+	}
+
+	key := types.NamespacedName{Name: n.ingress.Name, Namespace: n.ingress.Namespace}.String()
+	endpoints := make([]ingressv1.Endpoint, 0, len(addresses))
+	for _, a := range addresses {
+		endpoints = append(endpoints, ingressv1.Endpoint{Address: a, Down: !health.Healthy(key, a)})
+	}
+
+	return endpoints, nil
+}
+
 func (n *NginxController) generateTlsFile() (map[string]ingressv1.SSLCert, error) {
+	var ht map[string]ingressv1.SSLCert
+	var err error
+
+	n.certs = make(map[string]*cert.Info)
+	n.rejected = make(map[string]error)
+
 	if len(n.ingress.Spec.TLS) > 0 {
-		return n.generateCaTlsFile()
+		ht, err = n.generateCaTlsFile()
+	} else if !n.certReady {
+		return make(map[string]ingressv1.SSLCert), nil
+	} else {
+		ht, err = n.generateCrdTlsFile()
 	}
 
-	return n.generateCrdTlsFile()
+	n.inspectTls(ht)
+
+	return ht, err
+}
+
+// inspectTls parses the certificate of every host, a certificate whose SANs don't cover its host is not rendered.
+// An empty host can't be covered by any certificate, it is dropped without being reported.
+func (n *NginxController) inspectTls(ht map[string]ingressv1.SSLCert) {
+	for host, ssl := range ht {
+		if host == "" {
+			delete(ht, host)
+			continue
+		}
+
+		data, err := os.ReadFile(ssl.TlsCrt)
+		if err != nil {
+			n.rejected[host] = err
+			delete(ht, host)
+			continue
+		}
+
+		info, err := cert.ParseCertificate(data)
+		if err != nil {
+			n.rejected[host] = fmt.Errorf("invalid certificate: %v", err)
+			delete(ht, host)
+			continue
+		}
+
+		if !info.Covers(host) {
+			n.rejected[host] = fmt.Errorf("certificate SANs %v don't cover host %s", info.DNSNames, host)
+			delete(ht, host)
+			continue
+		}
+
+		n.certs[host] = info
+	}
+}
+
+// Certificates returns the certificates served by host, it is filled once the configuration is generated.
+func (n *NginxController) Certificates() map[string]*cert.Info {
+	return n.certs
+}
+
+// RejectedCertificates returns why the certificate of a host was not rendered.
+func (n *NginxController) RejectedCertificates() map[string]error {
+	return n.rejected
 }
 
 // Use Kubernetes internal self signed certificates
 func (n *NginxController) generateCrdTlsFile() (map[string]ingressv1.SSLCert, error) {
-	var ssl = ingressv1.SSLCert{}
 	var ht = make(map[string]ingressv1.SSLCert)
 
 	key := types.NamespacedName{Name: n.ingress.Name + "-secret", Namespace: n.ingress.Namespace}
-	data, err := n.rr.GetTlsData(key)
+	ssl, err := n.storeTls(key)
 	if err != nil {
 		return ht, err
 	}
 
-	tlsPrefix := n.ingress.Name + "-" + n.ingress.Namespace + "-"
+	// a rule without host has no name in the certificate, see the dnsNames of the Certificate
+	for _, v := range n.ingress.Spec.Rules {
+		if v.Host != "" {
+			ht[v.Host] = ssl
+		}
+	}
 
-	for k, v := range data {
-		file := filepath.Join(config.SslPath, tlsPrefix+k)
-		if err := os.WriteFile(file, v, 0644); err != nil {
-			return ht, err
+	return ht, nil
+}
+
+// Certificate signed with CA, not test. The certificates are keyed by the hosts of the rules, a rule host uses the
+// tls entry naming it or, failing that, a wildcard tls entry covering it.
+func (n *NginxController) generateCaTlsFile() (map[string]ingressv1.SSLCert, error) {
+	var byTlsHost = make(map[string]ingressv1.SSLCert)
+	var ht = make(map[string]ingressv1.SSLCert)
+
+	var denied = make(map[string]error)
+
+	for _, secret := range n.ingress.Spec.TLS {
+		// without a secretName the hosts are served with the default certificate
+		if secret.SecretName == "" {
+			continue
+		}
+
+		ns, name := ingressv1.SecretRef(secret.SecretName, n.ingress.Namespace)
+		if ns != n.ingress.Namespace {
+			if err := n.checkSecretGrant(ns, name); err != nil {
+				for _, host := range secret.Hosts {
+					denied[host] = err
+				}
+				continue
+			}
 		}
 
-		if k == config.TlsCrt {
-			ssl.TlsCrt = file
-		} else if k == config.TlsKey {
-			ssl.TlsKey = file
+		ssl, err := n.storeTls(types.NamespacedName{Name: name, Namespace: ns})
+		if err != nil {
+			return ht, err
 		}
 
+		for _, host := range secret.Hosts {
+			if !parser.IsValidHost(host) {
+				return ht, fmt.Errorf("%s not a valid host", host)
+			}
+			byTlsHost[host] = ssl
+		}
 	}
 
 	for _, v := range n.ingress.Spec.Rules {
-		ssl.TlsNoPass = true
-		ht[v.Host] = ssl
+		if ssl, ok := byTlsHost[v.Host]; ok {
+			ht[v.Host] = ssl
+		} else if ssl, ok := byTlsHost[wildcardOf(v.Host)]; ok {
+			ht[v.Host] = ssl
+		} else if err, ok := denied[v.Host]; ok {
+			n.rejected[v.Host] = err
+		} else if err, ok := denied[wildcardOf(v.Host)]; ok {
+			n.rejected[v.Host] = err
+		}
 	}
 
 	return ht, nil
 }
 
-// Certificate signed with CA, not test
-func (n *NginxController) generateCaTlsFile() (map[string]ingressv1.SSLCert, error) {
-	var ssl = ingressv1.SSLCert{}
-	var ht = make(map[string]ingressv1.SSLCert)
+// checkSecretGrant returns an errRefNotPermitted error unless a SecretGrant of namespace lets the namespace of the
+// ingress use the Secret name.
+func (n *NginxController) checkSecretGrant(namespace, name string) error {
+	var grants ingressv1.SecretGrantList
+	if err := n.client.List(n.ctx, &grants, client.InNamespace(namespace)); err != nil {
+		return fmt.Errorf("unable to list secretgrants in namespace: %s, %v", namespace, err)
+	}
 
-	for _, secret := range n.ingress.Spec.TLS {
-		for _, host := range secret.Hosts {
-			key := types.NamespacedName{Name: secret.SecretName, Namespace: n.ingress.Namespace}
-			data, err := n.rr.GetTlsData(key)
-			if err != nil {
-				return ht, err
-			}
-			for k, v := range data {
-				hf := parser.GetDnsRegex(host)
-				if hf == "" {
-					return ht, fmt.Errorf("%s not a valid host", host)
-				}
-				file := filepath.Join(config.SslPath, host+"-"+n.ingress.Namespace+"-"+k)
-				if err := os.WriteFile(file, v, 0644); err != nil {
-					return ht, err
-				}
-				if k == config.TlsCrt {
-					ssl.TlsCrt = file
-				} else if k == config.TlsKey {
-					ssl.TlsKey = file
-				}
-			}
-			ssl.TlsNoPass = true
-			ht[host] = ssl
+	for i := range grants.Items {
+		if grants.Items[i].Permits(n.ingress.Namespace, name) {
+			return nil
 		}
 	}
 
-	return ht, nil
+	return fmt.Errorf("%w: secret %s/%s is not granted to namespace %s by a secretgrant", errRefNotPermitted, namespace, name, n.ingress.Namespace)
 }
 
-func (n *NginxController) formatPath(path string, ingress annotations.IngressAnnotations) string {
-	if ingress.ParsedAnnotations.Rewrite.EnableRegex || ingress.ParsedAnnotations.Rewrite.RewriteTarget != "" {
-		path = "~ ^" + path
+// wildcardOf returns the wildcard host covering host, *.example.com for api.example.com. A wildcard only covers
+// a single label, a host that is already a wildcard or has a single label has none.
+func wildcardOf(host string) string {
+	if strings.HasPrefix(host, "*.") {
+		return ""
+	}
+
+	_, parent, found := strings.Cut(host, ".")
+	if !found || !strings.Contains(parent, ".") {
+		return ""
 	}
 
-	return path
+	return "*." + parent
+}
+
+// storeTls writes the tls Secret key to the certificate store.
+func (n *NginxController) storeTls(key types.NamespacedName) (ingressv1.SSLCert, error) {
+	var ssl = ingressv1.SSLCert{}
+
+	data, err := n.rr.GetTlsData(key)
+	if err != nil {
+		return ssl, err
+	}
+
+	bundle, err := certstore.Write(data[config.TlsCrt], data[config.TlsKey])
+	if err != nil {
+		return ssl, fmt.Errorf("secret: %s, in namespace: %s: %v", key.Name, key.Namespace, err)
+	}
+
+	ssl.TlsCrt = bundle
+	ssl.TlsKey = bundle
+	ssl.TlsNoPass = true
+
+	return ssl, nil
 }
 
 func (n *NginxController) checkIngressContent(path *ingressv1.HTTPIngressPath, annotations *annotations.Ingress) error {
 	var err error
 	var info string
-	if annotations.Rewrite.EnableRegex && *path.PathType != "ImplementationSpecific" {
-		klog.Warningf(
-			fmt.Sprintf("the value of pathType should be define as ImplementationSpecific in ingress: %s, namespace: %s", n.ingress.Name, n.ingress.Namespace))
+	if usesRegex(annotations) && pathType(*path) != ingressv1.PathTypeImplementationSpecific {
+		err = fmt.Errorf("the pathType of path: %s should be ImplementationSpecific because enable-regex or rewrite-target is used in annotations", path.Path)
+		klog.ErrorS(err, fmt.Sprintf("invalid pathType in ingress: %s, namespace: %s", n.ingress.Name, n.ingress.Namespace))
+		return err
 	}
 
 	if parser.IsRegexPatternRegex(path.Path) && !annotations.Rewrite.EnableRegex && annotations.Rewrite.RewriteTarget == "" {
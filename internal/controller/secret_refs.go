@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	ingressv1 "github.com/ingoxx/ingress-nginx-kubebuilder/api/v1"
+	netv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+//+kubebuilder:rbac:groups=ingress.nginx.kubebuilder.io,resources=secretgrants,verbs=get;list;watch
+
+// tlsRefs is what the Secret and SecretGrant watches need to know about an ingress of either API.
+type tlsRefs struct {
+	key         types.NamespacedName
+	className   string
+	annotations map[string]string
+	tls         []netv1.IngressTLS
+}
+
+// usesSecret reports whether the ingress renders the Secret namespace/name, either from its tls section or, without
+// one, the Secret of the certificate requested from cert-manager.
+func (t tlsRefs) usesSecret(namespace, name string) bool {
+	if len(t.tls) == 0 {
+		return namespace == t.key.Namespace && name == t.key.Name+"-secret"
+	}
+
+	for _, v := range t.tls {
+		if ns, n := ingressv1.SecretRef(v.SecretName, t.key.Namespace); ns == namespace && n == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// usesGrantsOf reports whether the ingress references a Secret of namespace, which needs a SecretGrant there.
+func (t tlsRefs) usesGrantsOf(namespace string) bool {
+	if namespace == t.key.Namespace {
+		return false
+	}
+
+	for _, v := range t.tls {
+		if ns, _ := ingressv1.SecretRef(v.SecretName, t.key.Namespace); ns == namespace {
+			return true
+		}
+	}
+
+	return false
+}
+
+// secretRequests maps a Secret or a SecretGrant to the served ingresses it affects.
+func (r *IngressReconciler) secretRequests(ctx context.Context, obj client.Object, refs []tlsRefs) []reconcile.Request {
+	var requests []reconcile.Request
+
+	_, isGrant := obj.(*ingressv1.SecretGrant)
+	for _, t := range refs {
+		if isGrant && !t.usesGrantsOf(obj.GetNamespace()) || !isGrant && !t.usesSecret(obj.GetNamespace(), obj.GetName()) {
+			continue
+		}
+
+		if r.matchClass(ctx, t.className, t.annotations) {
+			requests = append(requests, reconcile.Request{NamespacedName: t.key})
+		}
+	}
+
+	return requests
+}
+
+func (r *IngressReconciler) ingressesForSecret(ctx context.Context, obj client.Object) []reconcile.Request {
+	var list ingressv1.IngressList
+	if err := r.List(ctx, &list); err != nil {
+		klog.ErrorS(err, fmt.Sprintf("fail to list ingresses using secret: %s, namespace: %s", obj.GetName(), obj.GetNamespace()))
+		return nil
+	}
+
+	refs := make([]tlsRefs, 0, len(list.Items))
+	for _, ing := range list.Items {
+		refs = append(refs, tlsRefs{
+			key:         types.NamespacedName{Name: ing.Name, Namespace: ing.Namespace},
+			className:   ing.Spec.IngressClassName,
+			annotations: ing.GetAnnotations(),
+			tls:         ing.Spec.TLS,
+		})
+	}
+
+	return r.secretRequests(ctx, obj, refs)
+}
+
+func (r *CoreIngressReconciler) ingressesForSecret(ctx context.Context, obj client.Object) []reconcile.Request {
+	var list netv1.IngressList
+	if err := r.List(ctx, &list); err != nil {
+		klog.ErrorS(err, fmt.Sprintf("fail to list ingresses using secret: %s, namespace: %s", obj.GetName(), obj.GetNamespace()))
+		return nil
+	}
+
+	refs := make([]tlsRefs, 0, len(list.Items))
+	for _, ing := range list.Items {
+		var className string
+		if ing.Spec.IngressClassName != nil {
+			className = *ing.Spec.IngressClassName
+		}
+
+		refs = append(refs, tlsRefs{
+			key:         types.NamespacedName{Name: ing.Name, Namespace: ing.Namespace},
+			className:   className,
+			annotations: ing.GetAnnotations(),
+			tls:         ing.Spec.TLS,
+		})
+	}
+
+	return r.secretRequests(ctx, obj, refs)
+}
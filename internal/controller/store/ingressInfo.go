@@ -7,10 +7,14 @@ import (
 	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations"
 	utils "github.com/ingoxx/ingress-nginx-kubebuilder/pkg/utils/cert"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog/v2"
+	"net"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"slices"
+	"strconv"
 )
 
 type IngressInfo struct {
@@ -122,27 +126,87 @@ func (t *IngressInfo) GetSecret(key client.ObjectKey) (*corev1.Secret, error) {
 
 	if err := t.r.Get(t.ctx, key, sc); err != nil {
 		if errors.IsNotFound(err) {
-			return sc, fmt.Errorf("secret: %s not fount in namespace: %s", t.ingress.Name+"-secret", t.ingress.Namespace)
+			return sc, fmt.Errorf("secret: %s not fount in namespace: %s", key.Name, key.Namespace)
 		}
 
-		return sc, fmt.Errorf("unexpected error searching service with name %v in namespace %v: %v", t.ingress.Name+"-secret", t.ingress.Namespace, err)
+		return sc, fmt.Errorf("unexpected error searching secret with name %v in namespace %v: %v", key.Name, key.Namespace, err)
 	}
 
 	return sc, nil
 }
 
+func (t *IngressInfo) GetConfigMap(name string) (*corev1.ConfigMap, error) {
+	cm := new(corev1.ConfigMap)
+	if err := t.r.Get(t.ctx, types.NamespacedName{Name: name, Namespace: t.ingress.Namespace}, cm); err != nil {
+		if errors.IsNotFound(err) {
+			return cm, fmt.Errorf("configmap: %s not fount in namespace: %s", name, t.ingress.Namespace)
+		}
+
+		return cm, fmt.Errorf("unexpected error searching configmap with name %v in namespace %v: %v", name, t.ingress.Namespace, err)
+	}
+
+	return cm, nil
+}
+
+// GetEndpoints returns the addresses of the ready endpoints behind port of the Service name.
+func (t *IngressInfo) GetEndpoints(name string, port int32) ([]string, error) {
+	svc, err := t.GetService(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var portName string
+	var found bool
+	for _, p := range svc.Spec.Ports {
+		if p.Port == port {
+			portName, found = p.Name, true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("port: %d not exists in service: %s, namespace: %s", port, name, t.ingress.Namespace)
+	}
+
+	var list discoveryv1.EndpointSliceList
+	if err := t.r.List(t.ctx, &list, client.InNamespace(t.ingress.Namespace), client.MatchingLabels{discoveryv1.LabelServiceName: name}); err != nil {
+		return nil, fmt.Errorf("unexpected error listing endpointslices of service %v in namespace %v: %v", name, t.ingress.Namespace, err)
+	}
+
+	var addresses []string
+	for _, es := range list.Items {
+		for _, p := range es.Ports {
+			if p.Port == nil || p.Name != nil && *p.Name != portName || p.Name == nil && portName != "" {
+				continue
+			}
+
+			for _, ep := range es.Endpoints {
+				if ep.Conditions.Ready != nil && !*ep.Conditions.Ready {
+					continue
+				}
+				for _, addr := range ep.Addresses {
+					addresses = append(addresses, net.JoinHostPort(addr, strconv.Itoa(int(*p.Port))))
+				}
+			}
+		}
+	}
+
+	slices.Sort(addresses)
+
+	return slices.Compact(addresses), nil
+}
+
 func (t *IngressInfo) GetTlsData(key client.ObjectKey) (map[string][]byte, error) {
 	var data map[string][]byte
 
 	secret, err := t.GetSecret(key)
 	if err != nil {
-		klog.ErrorS(err, fmt.Sprintf("fail to get secret: %s, in namespace: %s", t.ingress.Name+"-secret", t.ingress.Namespace))
+		klog.ErrorS(err, fmt.Sprintf("fail to get secret: %s, in namespace: %s", key.Name, key.Namespace))
 		return data, err
 	}
 
 	data, err = utils.DecodeBase64(secret.Data)
 	if err != nil {
-		klog.ErrorS(err, fmt.Sprintf("decoding tls failed, secret: %s, in namespace: %s", t.ingress.Name+"-secret", t.ingress.Namespace))
+		klog.ErrorS(err, fmt.Sprintf("decoding tls failed, secret: %s, in namespace: %s", key.Name, key.Namespace))
 		return data, err
 	}
 
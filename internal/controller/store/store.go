@@ -21,6 +21,8 @@ type IngressReconciler struct {
 	Context          context.Context
 	IngressInfos     *IngressInfo
 	DynamicClientSet *dynamic.DynamicClient
+	// CertReady reports whether the cert-manager certificate of an ingress without spec.tls can be served
+	CertReady bool
 }
 
 func (i *IngressReconciler) ReconcilerInfo() *IngressReconciler {
@@ -0,0 +1,233 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	ingressv1 "github.com/ingoxx/ingress-nginx-kubebuilder/api/v1"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/certstore"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/config"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/stream"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sort"
+	"time"
+)
+
+// TransportServerReconciler renders every TransportServer of the served class into the stream context,
+// a port can only be owned by one of them so they are always rendered together.
+type TransportServerReconciler struct {
+	IngressReconciler
+}
+
+//+kubebuilder:rbac:groups=ingress.nginx.kubebuilder.io,resources=transportservers,verbs=get;list;watch
+//+kubebuilder:rbac:groups=ingress.nginx.kubebuilder.io,resources=transportservers/status,verbs=get;update;patch
+
+func (r *TransportServerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var list ingressv1.TransportServerList
+	if err := r.List(ctx, &list); err != nil {
+		klog.ErrorS(err, "fail to list transportservers")
+		return ctrl.Result{RequeueAfter: time.Second * time.Duration(15)}, nil
+	}
+
+	var served []*ingressv1.TransportServer
+	for i := range list.Items {
+		ts := &list.Items[i]
+		if ts.DeletionTimestamp.IsZero() && r.matchClass(ctx, ts.Spec.IngressClassName, nil) {
+			served = append(served, ts)
+		}
+	}
+
+	// the oldest TransportServer keeps a contested port
+	sort.SliceStable(served, func(i, j int) bool {
+		if !served[i].CreationTimestamp.Equal(&served[j].CreationTimestamp) {
+			return served[i].CreationTimestamp.Before(&served[j].CreationTimestamp)
+		}
+		return served[i].Namespace+"/"+served[i].Name < served[j].Namespace+"/"+served[j].Name
+	})
+
+	listeners, invalid := r.listeners(ctx, served)
+	stream.SetTransportServers(listeners)
+	rejected, renderErr := stream.Sync()
+	for k, v := range invalid {
+		rejected[k] = v
+	}
+
+	if renderErr != nil {
+		klog.ErrorS(renderErr, "fail to render the stream configuration")
+	}
+
+	for _, ts := range served {
+		cond := metav1.Condition{
+			Type:               ingressv1.TransportServerReady,
+			Status:             metav1.ConditionTrue,
+			Reason:             "Rendered",
+			Message:            fmt.Sprintf("listening on %d/%s", ts.Spec.Listener.Port, ts.Protocol()),
+			ObservedGeneration: ts.Generation,
+		}
+
+		if err, ok := rejected[transportServerName(ts)]; ok {
+			cond.Status = metav1.ConditionFalse
+			cond.Reason = "Rejected"
+			cond.Message = err.Error()
+		} else if renderErr != nil {
+			cond.Status = metav1.ConditionFalse
+			cond.Reason = "RenderFailed"
+			cond.Message = renderErr.Error()
+		}
+
+		if err := r.updateStatus(ctx, ts, cond); err != nil {
+			klog.ErrorS(err, fmt.Sprintf("fail to update status of transportserver: %s, namespace: %s", ts.Name, ts.Namespace))
+		}
+	}
+
+	if renderErr != nil {
+		return ctrl.Result{RequeueAfter: time.Second * time.Duration(15)}, nil
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// listeners resolves the backend and the certificate of every TransportServer,
+// the ones that can't be rendered are returned in the map keyed by their listener name.
+func (r *TransportServerReconciler) listeners(ctx context.Context, served []*ingressv1.TransportServer) ([]stream.Listener, map[string]error) {
+	var listeners []stream.Listener
+	invalid := make(map[string]error)
+
+	for _, ts := range served {
+		name := transportServerName(ts)
+		if err := ts.ValidSpec(); err != nil {
+			invalid[name] = err
+			continue
+		}
+
+		svc := new(v1.Service)
+		if err := r.Get(ctx, types.NamespacedName{Name: ts.Spec.Upstream.ServiceName, Namespace: ts.Namespace}, svc); err != nil {
+			invalid[name] = fmt.Errorf("no service with name %s found in namespace %s", ts.Spec.Upstream.ServiceName, ts.Namespace)
+			continue
+		}
+
+		l := stream.Listener{
+			Name: name,
+			Port: ts.Spec.Listener.Port,
+			UDP:  ts.Protocol() == ingressv1.TransportProtocolUDP,
+			Servers: []ingressv1.UpstreamServer{{
+				Address: fmt.Sprintf("%s.%s.svc:%d", ts.Spec.Upstream.ServiceName, ts.Namespace, ts.Spec.Upstream.ServicePort),
+			}},
+		}
+
+		if ts.Spec.TLS != nil {
+			switch ts.Spec.TLS.Mode {
+			case ingressv1.TransportTLSPassthrough:
+				l.Passthrough = true
+				l.Host = ts.Spec.TLS.Host
+			case ingressv1.TransportTLSTerminate:
+				ssl, err := r.certificate(ctx, ts)
+				if err != nil {
+					invalid[name] = err
+					continue
+				}
+				l.Tls = ssl
+			}
+		}
+
+		listeners = append(listeners, l)
+	}
+
+	return listeners, invalid
+}
+
+// certificate writes the tls Secret of a terminating TransportServer to the certificate store.
+func (r *TransportServerReconciler) certificate(ctx context.Context, ts *ingressv1.TransportServer) (ingressv1.SSLCert, error) {
+	var ssl ingressv1.SSLCert
+
+	secret := new(v1.Secret)
+	if err := r.Get(ctx, types.NamespacedName{Name: ts.Spec.TLS.SecretName, Namespace: ts.Namespace}, secret); err != nil {
+		return ssl, fmt.Errorf("fail to get secret: %s, in namespace: %s", ts.Spec.TLS.SecretName, ts.Namespace)
+	}
+
+	bundle, err := certstore.Write(secret.Data[config.TlsCrt], secret.Data[config.TlsKey])
+	if err != nil {
+		return ssl, fmt.Errorf("secret: %s, in namespace: %s: %v", ts.Spec.TLS.SecretName, ts.Namespace, err)
+	}
+
+	ssl.TlsCrt = bundle
+	ssl.TlsKey = bundle
+	ssl.TlsNoPass = true
+
+	return ssl, nil
+}
+
+func (r *TransportServerReconciler) updateStatus(ctx context.Context, ts *ingressv1.TransportServer, cond metav1.Condition) error {
+	if c := meta.FindStatusCondition(ts.Status.Conditions, cond.Type); c != nil &&
+		c.Status == cond.Status && c.Reason == cond.Reason && c.Message == cond.Message && c.ObservedGeneration == cond.ObservedGeneration {
+		return nil
+	}
+
+	meta.SetStatusCondition(&ts.Status.Conditions, cond)
+
+	return r.Status().Update(ctx, ts)
+}
+
+func transportServerName(ts *ingressv1.TransportServer) string {
+	return ts.Name + "-" + ts.Namespace
+}
+
+func (r *TransportServerReconciler) classPredicate() predicate.Funcs {
+	return newClassPredicate(func(obj client.Object) bool {
+		ts, ok := obj.(*ingressv1.TransportServer)
+		if !ok {
+			return false
+		}
+
+		return r.matchClass(context.Background(), ts.Spec.IngressClassName, nil)
+	})
+}
+
+// transportServersForObject maps a Service or a Secret to the TransportServers of its namespace using it, a
+// TransportServer rejected because it was missing is rendered once it is created.
+func (r *TransportServerReconciler) transportServersForObject(ctx context.Context, obj client.Object) []reconcile.Request {
+	var list ingressv1.TransportServerList
+	if err := r.List(ctx, &list, client.InNamespace(obj.GetNamespace())); err != nil {
+		klog.ErrorS(err, fmt.Sprintf("fail to list transportservers using: %s, namespace: %s", obj.GetName(), obj.GetNamespace()))
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, ts := range list.Items {
+		switch obj.(type) {
+		case *v1.Service:
+			if ts.Spec.Upstream.ServiceName != obj.GetName() {
+				continue
+			}
+		case *v1.Secret:
+			if ts.Spec.TLS == nil || ts.Spec.TLS.SecretName != obj.GetName() {
+				continue
+			}
+		}
+
+		if r.matchClass(ctx, ts.Spec.IngressClassName, nil) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: ts.Name, Namespace: ts.Namespace}})
+		}
+	}
+
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager, nginx itself is started by the IngressReconciler.
+func (r *TransportServerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("transportserver").
+		For(&ingressv1.TransportServer{}, builder.WithPredicates(r.classPredicate())).
+		Watches(&v1.Service{}, handler.EnqueueRequestsFromMapFunc(r.transportServersForObject)).
+		Watches(&v1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.transportServersForObject)).
+		Complete(r)
+}
@@ -18,6 +18,18 @@ func (e RiskyAnnotationError) Error() string {
 	return e.Reason.Error()
 }
 
+// NewRiskyAnnotationsError reports an annotation refused by the policy of the risky annotations.
+func NewRiskyAnnotationsError(msg string) error {
+	return RiskyAnnotationError{
+		Reason: errors.New(msg),
+	}
+}
+
+func IsRiskyAnnotationError(e error) bool {
+	var riskyAnnotationError RiskyAnnotationError
+	return errors.As(e, &riskyAnnotationError)
+}
+
 func IsMissingAnnotations(e error) bool {
 	return errors.Is(e, ErrMissingAnnotations)
 }
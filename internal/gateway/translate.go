@@ -0,0 +1,644 @@
+package gateway
+
+import (
+	"fmt"
+	ingressv1 "github.com/ingoxx/ingress-nginx-kubebuilder/api/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	internalLocationPrefix = "/_gw/"
+	defaultServerName      = "_"
+)
+
+var (
+	headerNameRegex = regexp.MustCompile(`^[A-Za-z0-9!#$%&'*+\-.^_|~]+$`)
+	queryNameRegex  = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+)
+
+// RouteStatus is the outcome of translating a HTTPRoute for one Gateway.
+type RouteStatus struct {
+	Accepted       bool
+	AcceptedReason string
+	ResolvedRefs   bool
+	RefsReason     string
+	Message        string
+}
+
+// ListenerStatus is the outcome of translating a Gateway listener.
+type ListenerStatus struct {
+	Name           string
+	Accepted       bool
+	Reason         string
+	Message        string
+	AttachedRoutes int32
+}
+
+type Result struct {
+	Configuration *ingressv1.Configuration
+	Routes        map[types.NamespacedName]*RouteStatus
+	Listeners     []*ListenerStatus
+}
+
+// Translator turns a Gateway and the HTTPRoutes attached to it into the nginx configuration model.
+type Translator struct {
+	Gateway *Gateway
+	// Routes are sorted by creation time then by namespace and name, older routes win on conflicting matches as
+	// required by the Gateway API
+	Routes []*HTTPRoute
+	// Certificates are keyed by listener name
+	Certificates map[string]ingressv1.SSLCert
+	// exact marks the matches of Exact paths
+	exact map[*ingressv1.RouteMatch]bool
+}
+
+type serverKey struct {
+	port int32
+	host string
+}
+
+func (t *Translator) Translate() *Result {
+	res := &Result{
+		Configuration: &ingressv1.Configuration{},
+		Routes:        make(map[types.NamespacedName]*RouteStatus),
+	}
+
+	t.exact = make(map[*ingressv1.RouteMatch]bool)
+	sort.SliceStable(t.Routes, func(i, j int) bool {
+		ci, cj := t.Routes[i].CreationTimestamp, t.Routes[j].CreationTimestamp
+		if !ci.Equal(&cj) {
+			return ci.Before(&cj)
+		}
+		if t.Routes[i].Namespace != t.Routes[j].Namespace {
+			return t.Routes[i].Namespace < t.Routes[j].Namespace
+		}
+
+		return t.Routes[i].Name < t.Routes[j].Name
+	})
+
+	servers := make(map[serverKey]*ingressv1.Server)
+	var order []serverKey
+
+	for _, l := range t.Gateway.Spec.Listeners {
+		ls := &ListenerStatus{Name: l.Name, Accepted: true}
+		res.Listeners = append(res.Listeners, ls)
+
+		if l.Protocol != HTTPProtocolType && l.Protocol != HTTPSProtocolType {
+			ls.Accepted = false
+			ls.Reason = "UnsupportedProtocol"
+			ls.Message = fmt.Sprintf("protocol %s is not supported", l.Protocol)
+			continue
+		}
+
+		tls, hasTls := t.Certificates[l.Name]
+		if l.Protocol == HTTPSProtocolType && !hasTls {
+			ls.Accepted = false
+			ls.Reason = "InvalidCertificateRef"
+			ls.Message = fmt.Sprintf("no usable certificate found for listener %s", l.Name)
+			continue
+		}
+
+		for _, route := range t.Routes {
+			key := types.NamespacedName{Name: route.Name, Namespace: route.Namespace}
+			rs, ok := res.Routes[key]
+			if !ok {
+				rs = &RouteStatus{AcceptedReason: "NoMatchingParent", ResolvedRefs: true, RefsReason: "ResolvedRefs"}
+				res.Routes[key] = rs
+			}
+
+			if !t.attaches(route, l) {
+				continue
+			}
+
+			hosts := intersectHostnames(l.Hostname, route.Spec.Hostnames)
+			if len(hosts) == 0 {
+				if !rs.Accepted {
+					rs.AcceptedReason = "NoMatchingListenerHostname"
+				}
+				continue
+			}
+
+			if err := t.translateRoute(res.Configuration, route, rs, func(host string) *ingressv1.Server {
+				sk := serverKey{port: l.Port, host: host}
+				s, ok := servers[sk]
+				if !ok {
+					s = &ingressv1.Server{
+						Name:      t.Gateway.Name,
+						NameSpace: t.Gateway.Namespace,
+						HostName:  host,
+						Port:      l.Port,
+					}
+					if l.Protocol == HTTPSProtocolType {
+						s.Tls = tls
+					}
+					servers[sk] = s
+					order = append(order, sk)
+				}
+				return s
+			}, hosts); err != nil {
+				rs.Accepted = false
+				rs.AcceptedReason = "UnsupportedValue"
+				rs.Message = err.Error()
+				continue
+			}
+
+			rs.Accepted = true
+			rs.AcceptedReason = "Accepted"
+			ls.AttachedRoutes++
+		}
+	}
+
+	for _, sk := range order {
+		s := servers[sk]
+		for _, b := range s.Paths {
+			completeMatches(b)
+		}
+		res.Configuration.Servers = append(res.Configuration.Servers, s)
+	}
+
+	return res
+}
+
+// attaches reports whether the route references the gateway through a parentRef selecting listener l.
+func (t *Translator) attaches(route *HTTPRoute, l Listener) bool {
+	for _, ref := range route.Spec.ParentRefs {
+		if ref.Group != nil && *ref.Group != Group {
+			continue
+		}
+		if ref.Kind != nil && *ref.Kind != GatewayGVK.Kind {
+			continue
+		}
+
+		ns := route.Namespace
+		if ref.Namespace != nil {
+			ns = *ref.Namespace
+		}
+		if ref.Name != t.Gateway.Name || ns != t.Gateway.Namespace {
+			continue
+		}
+		if ref.SectionName != nil && *ref.SectionName != l.Name {
+			continue
+		}
+		if ref.Port != nil && *ref.Port != l.Port {
+			continue
+		}
+
+		from := NamespacesFromSame
+		if l.AllowedRoutes != nil && l.AllowedRoutes.Namespaces != nil && l.AllowedRoutes.Namespaces.From != nil {
+			from = *l.AllowedRoutes.Namespaces.From
+		}
+		if from == NamespacesFromSame && route.Namespace != t.Gateway.Namespace {
+			continue
+		}
+		if from != NamespacesFromSame && from != NamespacesFromAll {
+			continue
+		}
+
+		return true
+	}
+
+	return false
+}
+
+func (t *Translator) translateRoute(cfg *ingressv1.Configuration, route *HTTPRoute, rs *RouteStatus,
+	server func(host string) *ingressv1.Server, hosts []string) error {
+	for ri, rule := range route.Spec.Rules {
+		upstream := t.upstreamName(route, ri)
+		up, err := t.upstream(upstream, route, rule, rs)
+		if err != nil {
+			return err
+		}
+
+		matches := rule.Matches
+		if len(matches) == 0 {
+			matches = []HTTPRouteMatch{{}}
+		}
+
+		for mi, m := range matches {
+			path, locations, err := pathLocation(m.Path)
+			if err != nil {
+				return err
+			}
+
+			for li, location := range locations {
+				// each location of a prefix has its own internal locations for the matches with conditions
+				name := fmt.Sprintf("%s-m%d", upstream, mi)
+				if li > 0 {
+					name = fmt.Sprintf("%s-l%d", name, li)
+				}
+
+				rm, err := t.routeMatch(name, m, rule.Filters, path)
+				if err != nil {
+					return err
+				}
+
+				if len(up.Servers) > 0 {
+					rm.UpstreamName = up.Name
+					addUpstream(cfg, up)
+				} else if rm.Return == "" {
+					rm.Return = "return 500;"
+				}
+
+				for _, host := range hosts {
+					s := server(host)
+					b := findBackend(s, location)
+					if b == nil {
+						b = &ingressv1.Backend{
+							Name:       route.Name,
+							IngName:    route.Name,
+							NameSpace:  route.Namespace,
+							Path:       location,
+							TargetPath: path,
+						}
+						s.Paths = append(s.Paths, b)
+					}
+					t.addMatch(b, rm, isExact(m.Path))
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// addMatch appends a copy of rm to the matches of b. The exact location of a prefix is shared with an Exact match
+// of the same path, the Exact matches go first as they take precedence over the prefixes.
+func (t *Translator) addMatch(b *ingressv1.Backend, rm *ingressv1.RouteMatch, exact bool) {
+	cp := *rm
+	if !exact {
+		b.Matches = append(b.Matches, &cp)
+		return
+	}
+
+	t.exact[&cp] = true
+
+	i := slices.IndexFunc(b.Matches, func(m *ingressv1.RouteMatch) bool { return !t.exact[m] })
+	if i < 0 {
+		i = len(b.Matches)
+	}
+	b.Matches = slices.Insert(b.Matches, i, &cp)
+}
+
+func (t *Translator) upstreamName(route *HTTPRoute, rule int) string {
+	return fmt.Sprintf("gw-%s-%s-%s-%s-r%d", t.Gateway.Namespace, t.Gateway.Name, route.Namespace, route.Name, rule)
+}
+
+func (t *Translator) upstream(name string, route *HTTPRoute, rule HTTPRouteRule, rs *RouteStatus) (*ingressv1.Upstream, error) {
+	up := &ingressv1.Upstream{Name: name}
+
+	for _, ref := range rule.BackendRefs {
+		if (ref.Group != nil && *ref.Group != "") || (ref.Kind != nil && *ref.Kind != "Service") {
+			rs.ResolvedRefs = false
+			rs.RefsReason = "InvalidKind"
+			continue
+		}
+
+		ns := route.Namespace
+		if ref.Namespace != nil {
+			ns = *ref.Namespace
+		}
+		if ns != route.Namespace {
+			rs.ResolvedRefs = false
+			rs.RefsReason = "RefNotPermitted"
+			continue
+		}
+
+		if ref.Port == nil {
+			return nil, fmt.Errorf("backendRef %s must specify a port", ref.Name)
+		}
+
+		weight := int32(DefaultBackendWeight)
+		if ref.Weight != nil {
+			weight = *ref.Weight
+		}
+		if weight == 0 {
+			continue
+		}
+
+		up.Servers = append(up.Servers, ingressv1.UpstreamServer{
+			Address: fmt.Sprintf("%s.%s.svc:%d", ref.Name, ns, *ref.Port),
+			Weight:  weight,
+		})
+	}
+
+	return up, nil
+}
+
+func (t *Translator) routeMatch(name string, m HTTPRouteMatch, filters []HTTPRouteFilter, path string) (*ingressv1.RouteMatch, error) {
+	rm := &ingressv1.RouteMatch{Name: name}
+
+	if m.Method != nil {
+		rm.Conditions = append(rm.Conditions, ingressv1.RouteCondition{Variable: "$request_method", Operator: "=", Value: *m.Method})
+	}
+
+	for _, h := range m.Headers {
+		if !headerNameRegex.MatchString(h.Name) {
+			return nil, fmt.Errorf("invalid header name: %s", h.Name)
+		}
+		op, err := matchOperator(h.Type, h.Value)
+		if err != nil {
+			return nil, err
+		}
+		variable := "$http_" + strings.ReplaceAll(strings.ToLower(h.Name), "-", "_")
+		rm.Conditions = append(rm.Conditions, ingressv1.RouteCondition{Variable: variable, Operator: op, Value: h.Value})
+	}
+
+	for _, q := range m.QueryParams {
+		if !queryNameRegex.MatchString(q.Name) {
+			return nil, fmt.Errorf("unsupported query parameter name: %s", q.Name)
+		}
+		op, err := matchOperator(q.Type, q.Value)
+		if err != nil {
+			return nil, err
+		}
+		rm.Conditions = append(rm.Conditions, ingressv1.RouteCondition{Variable: "$arg_" + q.Name, Operator: op, Value: q.Value})
+	}
+
+	rm.Expect = strings.Repeat("1", len(rm.Conditions))
+
+	// the optional group lets the same rewrite run inline and in the internal location of the match
+	prefix := "^(?:" + internalLocationPrefix + regexp.QuoteMeta(name) + ")?"
+	if len(rm.Conditions) > 0 {
+		rm.RewriteFrom = "^" + internalLocationPrefix + regexp.QuoteMeta(name) + "(.*)$"
+		rm.RewriteTo = "$1"
+	}
+
+	for _, f := range filters {
+		switch f.Type {
+		case FilterRequestHeaderModifier:
+			if f.RequestHeaderModifier == nil {
+				continue
+			}
+			// nginx can't append to a request header, rendering add like set would drop the values of the client
+			if len(f.RequestHeaderModifier.Add) > 0 {
+				return nil, fmt.Errorf("requestHeaderModifier add is not supported, use set")
+			}
+			for _, h := range f.RequestHeaderModifier.Set {
+				if !headerNameRegex.MatchString(h.Name) || !isSafeValue(h.Value) {
+					return nil, fmt.Errorf("invalid header: %s", h.Name)
+				}
+				rm.SetHeaders = append(rm.SetHeaders, ingressv1.Header{Name: h.Name, Value: h.Value})
+			}
+			for _, h := range f.RequestHeaderModifier.Remove {
+				if !headerNameRegex.MatchString(h) {
+					return nil, fmt.Errorf("invalid header: %s", h)
+				}
+				rm.SetHeaders = append(rm.SetHeaders, ingressv1.Header{Name: h})
+			}
+		case FilterRequestRedirect:
+			if f.RequestRedirect == nil {
+				continue
+			}
+			ret, err := redirectDirective(f.RequestRedirect, prefix, path)
+			if err != nil {
+				return nil, err
+			}
+			rm.Return = ret
+		case FilterURLRewrite:
+			if f.URLRewrite == nil {
+				continue
+			}
+			if f.URLRewrite.Hostname != nil {
+				if !isSafeValue(*f.URLRewrite.Hostname) {
+					return nil, fmt.Errorf("invalid hostname: %s", *f.URLRewrite.Hostname)
+				}
+				rm.HostRewrite = *f.URLRewrite.Hostname
+			}
+			if f.URLRewrite.Path != nil {
+				from, to, err := rewritePath(f.URLRewrite.Path, prefix, path)
+				if err != nil {
+					return nil, err
+				}
+				rm.RewriteFrom, rm.RewriteTo = from, to
+			}
+		default:
+			return nil, fmt.Errorf("filter %s is not supported", f.Type)
+		}
+	}
+
+	return rm, nil
+}
+
+func redirectDirective(r *HTTPRequestRedirectSpec, prefix, path string) (string, error) {
+	code := DefaultRedirectStatusCode
+	if r.StatusCode != nil {
+		code = *r.StatusCode
+	}
+	if code != 301 && code != 302 {
+		return "", fmt.Errorf("redirect status code %d is not supported", code)
+	}
+
+	scheme := "$scheme"
+	if r.Scheme != nil {
+		scheme = *r.Scheme
+	}
+	host := "$host"
+	if r.Hostname != nil {
+		host = *r.Hostname
+	}
+	var port string
+	if r.Port != nil {
+		port = ":" + strconv.Itoa(int(*r.Port))
+	}
+	if !isSafeValue(scheme) || !isSafeValue(host) {
+		return "", fmt.Errorf("invalid redirect target")
+	}
+	target := scheme + "://" + host + port
+
+	if r.Path == nil {
+		return fmt.Sprintf("return %d %s$request_uri;", code, target), nil
+	}
+
+	switch r.Path.Type {
+	case FullPathHTTPPathModifier:
+		if r.Path.ReplaceFullPath == nil || !isSafeValue(*r.Path.ReplaceFullPath) {
+			return "", fmt.Errorf("invalid replaceFullPath")
+		}
+		return fmt.Sprintf("return %d %s%s$is_args$args;", code, target, *r.Path.ReplaceFullPath), nil
+	case PrefixMatchHTTPPathModifier:
+		if r.Path.ReplacePrefixMatch == nil || !isSafeValue(*r.Path.ReplacePrefixMatch) {
+			return "", fmt.Errorf("invalid replacePrefixMatch")
+		}
+		flag := "redirect"
+		if code == 301 {
+			flag = "permanent"
+		}
+		from, to := prefixReplacement(path, *r.Path.ReplacePrefixMatch)
+		return fmt.Sprintf("rewrite %s%s %s%s %s;", prefix, from, target, to, flag), nil
+	}
+
+	return "", fmt.Errorf("path modifier %s is not supported", r.Path.Type)
+}
+
+func rewritePath(m *HTTPPathModifier, prefix, path string) (string, string, error) {
+	switch m.Type {
+	case FullPathHTTPPathModifier:
+		if m.ReplaceFullPath == nil || !isSafeValue(*m.ReplaceFullPath) {
+			return "", "", fmt.Errorf("invalid replaceFullPath")
+		}
+		return prefix + ".*$", *m.ReplaceFullPath, nil
+	case PrefixMatchHTTPPathModifier:
+		if m.ReplacePrefixMatch == nil || !isSafeValue(*m.ReplacePrefixMatch) {
+			return "", "", fmt.Errorf("invalid replacePrefixMatch")
+		}
+		from, to := prefixReplacement(path, *m.ReplacePrefixMatch)
+		return prefix + from, to, nil
+	}
+
+	return "", "", fmt.Errorf("path modifier %s is not supported", m.Type)
+}
+
+// prefixReplacement returns the regex matching the paths of the prefix path element by element and the replacement
+// of the prefix by repl, the rest of the path after the prefix is $1. A / is only added before a rest, so with a
+// replacement of /bar the path /foo becomes /bar and /foo/x becomes /bar/x.
+func prefixReplacement(path, repl string) (string, string) {
+	prefix := regexp.QuoteMeta(strings.TrimSuffix(path, "/"))
+	if repl = strings.TrimSuffix(repl, "/"); repl == "" {
+		return prefix + "(?:/(.*))?$", "/$1"
+	}
+
+	return prefix + "(/.*)?$", repl + "$1"
+}
+
+func isExact(p *HTTPPathMatch) bool {
+	return p != nil && p.Type != nil && *p.Type == PathMatchExact
+}
+
+// pathLocation returns the matched path and the nginx location arguments for it. A PathPrefix is split into an
+// exact location for the path itself and a prefix location ending with / so that /foo doesn't match /foobar.
+func pathLocation(p *HTTPPathMatch) (string, []string, error) {
+	pathType, value := PathMatchPathPrefix, DefaultPathPrefix
+	if p != nil {
+		if p.Type != nil {
+			pathType = *p.Type
+		}
+		if p.Value != nil {
+			value = *p.Value
+		}
+	}
+
+	if !isSafeValue(value) || strings.ContainsAny(value, " {};") {
+		return "", nil, fmt.Errorf("invalid path: %s", value)
+	}
+
+	switch pathType {
+	case PathMatchExact:
+		return value, []string{"= " + value}, nil
+	case PathMatchPathPrefix:
+		path := strings.TrimRight(value, "/")
+		if path == "" {
+			return value, []string{"/"}, nil
+		}
+		return value, []string{"= " + path, path + "/"}, nil
+	case PathMatchRegularExpression:
+		return value, []string{"~ " + value}, nil
+	}
+
+	return "", nil, fmt.Errorf("path match type %s is not supported", pathType)
+}
+
+func matchOperator(t *string, value string) (string, error) {
+	if !isSafeValue(value) {
+		return "", fmt.Errorf("invalid match value: %s", value)
+	}
+
+	if t == nil || *t == HeaderMatchExact {
+		if strings.Contains(value, "$") {
+			return "", fmt.Errorf("invalid match value: %s", value)
+		}
+		return "=", nil
+	}
+
+	if *t == HeaderMatchRegularExpression {
+		if _, err := regexp.Compile(value); err != nil {
+			return "", fmt.Errorf("invalid regular expression: %s", value)
+		}
+		return "~", nil
+	}
+
+	return "", fmt.Errorf("match type %s is not supported", *t)
+}
+
+// isSafeValue rejects values that would escape the quoted nginx string or the template they are embedded in.
+func isSafeValue(v string) bool {
+	return !strings.ContainsAny(v, "\"\\\r\n") && !strings.Contains(v, "{{") && !strings.Contains(v, "}}")
+}
+
+// completeMatches orders the matches of a location, the more conditions the higher the precedence, and makes sure
+// the location has exactly one match without conditions, answering 404 when no route matches.
+func completeMatches(b *ingressv1.Backend) {
+	sort.SliceStable(b.Matches, func(i, j int) bool {
+		return len(b.Matches[i].Conditions) > len(b.Matches[j].Conditions)
+	})
+
+	for i, m := range b.Matches {
+		if len(m.Conditions) == 0 {
+			b.Matches = b.Matches[:i+1]
+			return
+		}
+	}
+
+	b.Matches = append(b.Matches, &ingressv1.RouteMatch{Name: b.Name + "-not-found", Return: "return 404;"})
+}
+
+func findBackend(s *ingressv1.Server, location string) *ingressv1.Backend {
+	for _, b := range s.Paths {
+		if b.Path == location {
+			return b
+		}
+	}
+
+	return nil
+}
+
+func addUpstream(cfg *ingressv1.Configuration, up *ingressv1.Upstream) {
+	for _, v := range cfg.Upstreams {
+		if v.Name == up.Name {
+			return
+		}
+	}
+
+	cfg.Upstreams = append(cfg.Upstreams, up)
+}
+
+// intersectHostnames returns the server names a route is served under on a listener.
+func intersectHostnames(listener *string, route []string) []string {
+	if listener == nil || *listener == "" {
+		if len(route) == 0 {
+			return []string{defaultServerName}
+		}
+		return route
+	}
+
+	if len(route) == 0 {
+		return []string{*listener}
+	}
+
+	var hosts []string
+	for _, h := range route {
+		switch {
+		case h == *listener:
+			hosts = append(hosts, h)
+		case hostMatches(*listener, h):
+			hosts = append(hosts, h)
+		case hostMatches(h, *listener):
+			hosts = append(hosts, *listener)
+		}
+	}
+
+	return hosts
+}
+
+// hostMatches reports whether the wildcard pattern, e.g. *.example.com, covers host.
+func hostMatches(pattern, host string) bool {
+	if !strings.HasPrefix(pattern, "*.") {
+		return false
+	}
+
+	return strings.HasSuffix(host, pattern[1:]) && len(host) > len(pattern)-1
+}
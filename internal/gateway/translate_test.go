@@ -0,0 +1,294 @@
+package gateway
+
+import (
+	ingressv1 "github.com/ingoxx/ingress-nginx-kubebuilder/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// apply runs a rewrite of nginx on path with the regexp package, $1 being the only group used by the translator.
+func apply(t *testing.T, from, to, path string) (string, bool) {
+	t.Helper()
+
+	re, err := regexp.Compile(from)
+	if err != nil {
+		t.Fatalf("invalid regex %s: %v", from, err)
+	}
+
+	m := re.FindStringSubmatch(path)
+	if m == nil {
+		return "", false
+	}
+
+	var group string
+	if len(m) > 1 {
+		group = m[1]
+	}
+
+	return strings.ReplaceAll(to, "$1", group), true
+}
+
+func TestIntersectHostnames(t *testing.T) {
+	tests := []struct {
+		name     string
+		listener *string
+		route    []string
+		want     []string
+	}{
+		{name: "no hostnames", want: []string{defaultServerName}},
+		{name: "route only", route: []string{"a.example.com"}, want: []string{"a.example.com"}},
+		{name: "listener only", listener: ptr("a.example.com"), want: []string{"a.example.com"}},
+		{name: "same", listener: ptr("a.example.com"), route: []string{"a.example.com", "b.example.com"}, want: []string{"a.example.com"}},
+		{name: "wildcard listener", listener: ptr("*.example.com"), route: []string{"a.example.com", "example.com", "a.other.com"}, want: []string{"a.example.com"}},
+		{name: "wildcard route", listener: ptr("a.example.com"), route: []string{"*.example.com"}, want: []string{"a.example.com"}},
+		{name: "no intersection", listener: ptr("a.example.com"), route: []string{"b.example.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := intersectHostnames(tt.listener, tt.route); !slices.Equal(got, tt.want) {
+				t.Errorf("intersectHostnames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttaches(t *testing.T) {
+	gw := &Gateway{ObjectMeta: metav1.ObjectMeta{Name: "gw", Namespace: "infra"}}
+	all := &AllowedRoutes{Namespaces: &RouteNamespaces{From: ptr(NamespacesFromAll)}}
+
+	tests := []struct {
+		name      string
+		namespace string
+		ref       ParentReference
+		listener  Listener
+		want      bool
+	}{
+		{name: "same namespace", namespace: "infra", ref: ParentReference{Name: "gw"}, listener: Listener{Name: "http", Port: 80}, want: true},
+		{name: "other gateway", namespace: "infra", ref: ParentReference{Name: "other"}, listener: Listener{Name: "http", Port: 80}},
+		{name: "other namespace refused", namespace: "app", ref: ParentReference{Name: "gw", Namespace: ptr("infra")}, listener: Listener{Name: "http", Port: 80}},
+		{name: "other namespace allowed", namespace: "app", ref: ParentReference{Name: "gw", Namespace: ptr("infra")}, listener: Listener{Name: "http", Port: 80, AllowedRoutes: all}, want: true},
+		{name: "gateway of the route namespace", namespace: "app", ref: ParentReference{Name: "gw"}, listener: Listener{Name: "http", Port: 80, AllowedRoutes: all}},
+		{name: "section name", namespace: "infra", ref: ParentReference{Name: "gw", SectionName: ptr("http")}, listener: Listener{Name: "http", Port: 80}, want: true},
+		{name: "other section name", namespace: "infra", ref: ParentReference{Name: "gw", SectionName: ptr("https")}, listener: Listener{Name: "http", Port: 80}},
+		{name: "other port", namespace: "infra", ref: ParentReference{Name: "gw", Port: ptr(int32(443))}, listener: Listener{Name: "http", Port: 80}},
+		{name: "other kind", namespace: "infra", ref: ParentReference{Name: "gw", Kind: ptr("Service")}, listener: Listener{Name: "http", Port: 80}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &Translator{Gateway: gw}
+			route := &HTTPRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: tt.namespace},
+				Spec:       HTTPRouteSpec{ParentRefs: []ParentReference{tt.ref}},
+			}
+			if got := tr.attaches(route, tt.listener); got != tt.want {
+				t.Errorf("attaches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPathLocation(t *testing.T) {
+	tests := []struct {
+		name      string
+		match     *HTTPPathMatch
+		locations []string
+		wantErr   bool
+	}{
+		{name: "default", locations: []string{"/"}},
+		{name: "root prefix", match: &HTTPPathMatch{Type: ptr(PathMatchPathPrefix), Value: ptr("/")}, locations: []string{"/"}},
+		{name: "prefix", match: &HTTPPathMatch{Type: ptr(PathMatchPathPrefix), Value: ptr("/foo")}, locations: []string{"= /foo", "/foo/"}},
+		{name: "prefix with trailing slash", match: &HTTPPathMatch{Type: ptr(PathMatchPathPrefix), Value: ptr("/foo/")}, locations: []string{"= /foo", "/foo/"}},
+		{name: "exact", match: &HTTPPathMatch{Type: ptr(PathMatchExact), Value: ptr("/foo")}, locations: []string{"= /foo"}},
+		{name: "regex", match: &HTTPPathMatch{Type: ptr(PathMatchRegularExpression), Value: ptr("/foo/[0-9]+")}, locations: []string{"~ /foo/[0-9]+"}},
+		{name: "unknown type", match: &HTTPPathMatch{Type: ptr("Glob"), Value: ptr("/foo")}, wantErr: true},
+		{name: "block injection", match: &HTTPPathMatch{Value: ptr("/foo { return 200; }")}, wantErr: true},
+		{name: "template", match: &HTTPPathMatch{Value: ptr("/{{.}}")}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, locations, err := pathLocation(tt.match)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("pathLocation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(locations, tt.locations) {
+				t.Errorf("pathLocation() = %v, want %v", locations, tt.locations)
+			}
+		})
+	}
+}
+
+func TestRewritePath(t *testing.T) {
+	prefix := "^(?:" + internalLocationPrefix + "m)?"
+	tests := []struct {
+		name     string
+		modifier *HTTPPathModifier
+		path     string
+		request  string
+		want     string
+		noMatch  bool
+	}{
+		{name: "prefix itself", modifier: &HTTPPathModifier{Type: PrefixMatchHTTPPathModifier, ReplacePrefixMatch: ptr("/bar")}, path: "/foo", request: "/foo", want: "/bar"},
+		{name: "prefix with trailing slash", modifier: &HTTPPathModifier{Type: PrefixMatchHTTPPathModifier, ReplacePrefixMatch: ptr("/bar")}, path: "/foo", request: "/foo/", want: "/bar/"},
+		{name: "rest of the path", modifier: &HTTPPathModifier{Type: PrefixMatchHTTPPathModifier, ReplacePrefixMatch: ptr("/bar/")}, path: "/foo/", request: "/foo/x/y", want: "/bar/x/y"},
+		{name: "internal location", modifier: &HTTPPathModifier{Type: PrefixMatchHTTPPathModifier, ReplacePrefixMatch: ptr("/bar")}, path: "/foo", request: "/_gw/m/foo/x", want: "/bar/x"},
+		{name: "replaced by root", modifier: &HTTPPathModifier{Type: PrefixMatchHTTPPathModifier, ReplacePrefixMatch: ptr("/")}, path: "/foo", request: "/foo", want: "/"},
+		{name: "replaced by root with rest", modifier: &HTTPPathModifier{Type: PrefixMatchHTTPPathModifier, ReplacePrefixMatch: ptr("/")}, path: "/foo", request: "/foo/x", want: "/x"},
+		{name: "element by element", modifier: &HTTPPathModifier{Type: PrefixMatchHTTPPathModifier, ReplacePrefixMatch: ptr("/bar")}, path: "/foo", request: "/foobar", noMatch: true},
+		{name: "full path", modifier: &HTTPPathModifier{Type: FullPathHTTPPathModifier, ReplaceFullPath: ptr("/bar")}, path: "/foo", request: "/foo/x", want: "/bar"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, err := rewritePath(tt.modifier, prefix, tt.path)
+			if err != nil {
+				t.Fatalf("rewritePath() error = %v", err)
+			}
+
+			got, ok := apply(t, from, to, tt.request)
+			if ok == tt.noMatch {
+				t.Fatalf("rewrite %s of %s matches = %v, want %v", from, tt.request, ok, !tt.noMatch)
+			}
+			if got != tt.want {
+				t.Errorf("rewrite of %s = %s, want %s", tt.request, got, tt.want)
+			}
+		})
+	}
+
+	if _, _, err := rewritePath(&HTTPPathModifier{Type: PrefixMatchHTTPPathModifier, ReplacePrefixMatch: ptr(`/a"b`)}, prefix, "/foo"); err == nil {
+		t.Errorf("rewritePath() accepts a quote in the replacement")
+	}
+}
+
+func TestRedirectDirective(t *testing.T) {
+	prefix := "^(?:" + internalLocationPrefix + "m)?"
+	tests := []struct {
+		name    string
+		spec    *HTTPRequestRedirectSpec
+		want    string
+		wantErr bool
+	}{
+		{name: "default", spec: &HTTPRequestRedirectSpec{}, want: "return 302 $scheme://$host$request_uri;"},
+		{name: "scheme and port", spec: &HTTPRequestRedirectSpec{Scheme: ptr("https"), Port: ptr(int32(8443)), StatusCode: ptr(301)}, want: "return 301 https://$host:8443$request_uri;"},
+		{name: "full path", spec: &HTTPRequestRedirectSpec{Hostname: ptr("b.example.com"), Path: &HTTPPathModifier{Type: FullPathHTTPPathModifier, ReplaceFullPath: ptr("/bar")}}, want: "return 302 $scheme://b.example.com/bar$is_args$args;"},
+		{name: "prefix", spec: &HTTPRequestRedirectSpec{StatusCode: ptr(301), Path: &HTTPPathModifier{Type: PrefixMatchHTTPPathModifier, ReplacePrefixMatch: ptr("/bar")}}, want: `rewrite ^(?:/_gw/m)?/foo(/.*)?$ $scheme://$host/bar$1 permanent;`},
+		{name: "status code", spec: &HTTPRequestRedirectSpec{StatusCode: ptr(307)}, wantErr: true},
+		{name: "hostname injection", spec: &HTTPRequestRedirectSpec{Hostname: ptr(`a"; return 200 "x`)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := redirectDirective(tt.spec, prefix, "/foo")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("redirectDirective() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("redirectDirective() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// the prefix redirect of the matched path itself has no trailing /
+	got, _ := redirectDirective(&HTTPRequestRedirectSpec{Path: &HTTPPathModifier{Type: PrefixMatchHTTPPathModifier, ReplacePrefixMatch: ptr("/bar")}}, prefix, "/foo")
+	fields := strings.Fields(got)
+	for request, want := range map[string]string{"/foo": "$scheme://$host/bar", "/foo/x": "$scheme://$host/bar/x"} {
+		if target, _ := apply(t, fields[1], fields[2], request); target != want {
+			t.Errorf("redirect of %s = %s, want %s", request, target, want)
+		}
+	}
+}
+
+func newRoute(name, namespace string, created time.Time, rules ...HTTPRouteRule) *HTTPRoute {
+	return &HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, CreationTimestamp: metav1.NewTime(created)},
+		Spec: HTTPRouteSpec{
+			ParentRefs: []ParentReference{{Name: "gw", Namespace: ptr("infra")}},
+			Rules:      rules,
+		},
+	}
+}
+
+func backendRule(path string) HTTPRouteRule {
+	return HTTPRouteRule{
+		Matches:     []HTTPRouteMatch{{Path: &HTTPPathMatch{Type: ptr(PathMatchExact), Value: ptr(path)}}},
+		BackendRefs: []HTTPBackendRef{{Name: "svc", Port: ptr(int32(80))}},
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	gw := &Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "gw", Namespace: "infra"},
+		Spec: GatewaySpec{Listeners: []Listener{
+			{Name: "http", Port: 80, Protocol: HTTPProtocolType, AllowedRoutes: &AllowedRoutes{Namespaces: &RouteNamespaces{From: ptr(NamespacesFromAll)}}},
+			{Name: "https", Port: 443, Protocol: HTTPSProtocolType},
+			{Name: "tcp", Port: 9000, Protocol: "TCP"},
+		}},
+	}
+
+	// the routes are created in the same second, the conflict is settled by namespace then name
+	tr := &Translator{
+		Gateway: gw,
+		Routes: []*HTTPRoute{
+			newRoute("b", "app", created, backendRule("/foo")),
+			newRoute("a", "app", created, backendRule("/foo")),
+			newRoute("a", "zz", created, backendRule("/foo")),
+			newRoute("add", "app", created, HTTPRouteRule{
+				Filters: []HTTPRouteFilter{{Type: FilterRequestHeaderModifier, RequestHeaderModifier: &HTTPHeaderFilter{
+					Add: []HTTPHeader{{Name: "X-Foo", Value: "bar"}},
+				}}},
+			}),
+		},
+	}
+
+	res := tr.Translate()
+
+	listeners := make(map[string]*ListenerStatus)
+	for _, l := range res.Listeners {
+		listeners[l.Name] = l
+	}
+	if l := listeners["http"]; !l.Accepted || l.AttachedRoutes != 3 {
+		t.Errorf("listener http accepted = %v with %d routes, want 3 routes", l.Accepted, l.AttachedRoutes)
+	}
+	if l := listeners["https"]; l.Accepted || l.Reason != "InvalidCertificateRef" {
+		t.Errorf("listener https without certificate accepted = %v, reason %s", l.Accepted, l.Reason)
+	}
+	if l := listeners["tcp"]; l.Accepted || l.Reason != "UnsupportedProtocol" {
+		t.Errorf("listener tcp accepted = %v, reason %s", l.Accepted, l.Reason)
+	}
+
+	if rs := res.Routes[types.NamespacedName{Name: "add", Namespace: "app"}]; rs.Accepted || rs.AcceptedReason != "UnsupportedValue" {
+		t.Errorf("route with requestHeaderModifier add accepted = %v, reason %s", rs.Accepted, rs.AcceptedReason)
+	}
+
+	if len(res.Configuration.Servers) != 1 {
+		t.Fatalf("got %d servers, want 1", len(res.Configuration.Servers))
+	}
+
+	var location *ingressv1.Backend
+	for _, b := range res.Configuration.Servers[0].Paths {
+		if b.Path == "= /foo" {
+			location = b
+		}
+	}
+	if location == nil {
+		t.Fatalf("no location for /foo in %v", res.Configuration.Servers[0].Paths)
+	}
+	if want := tr.upstreamName(tr.Routes[0], 0); len(location.Matches) != 1 || location.Matches[0].UpstreamName != want {
+		t.Errorf("location /foo is served by %v, want the single upstream %s", location.Matches, want)
+	}
+	if tr.Routes[0].Name != "a" || tr.Routes[0].Namespace != "app" {
+		t.Errorf("first route is %s/%s, want app/a", tr.Routes[0].Namespace, tr.Routes[0].Name)
+	}
+}
@@ -0,0 +1,197 @@
+package gateway
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// The Gateway API objects are read as unstructured, like the cert-manager resources, and converted into the
+// subset of gateway.networking.k8s.io/v1 below, so the controller does not depend on the Gateway API module.
+
+const (
+	Group   = "gateway.networking.k8s.io"
+	Version = "v1"
+)
+
+var (
+	GatewayClassGVK = schema.GroupVersionKind{Group: Group, Version: Version, Kind: "GatewayClass"}
+	GatewayGVK      = schema.GroupVersionKind{Group: Group, Version: Version, Kind: "Gateway"}
+	HTTPRouteGVK    = schema.GroupVersionKind{Group: Group, Version: Version, Kind: "HTTPRoute"}
+)
+
+const (
+	PathMatchExact             = "Exact"
+	PathMatchPathPrefix        = "PathPrefix"
+	PathMatchRegularExpression = "RegularExpression"
+
+	HeaderMatchExact             = "Exact"
+	HeaderMatchRegularExpression = "RegularExpression"
+
+	FilterRequestHeaderModifier = "RequestHeaderModifier"
+	FilterRequestRedirect       = "RequestRedirect"
+	FilterURLRewrite            = "URLRewrite"
+
+	FullPathHTTPPathModifier    = "ReplaceFullPath"
+	PrefixMatchHTTPPathModifier = "ReplacePrefixMatch"
+
+	HTTPProtocolType  = "HTTP"
+	HTTPSProtocolType = "HTTPS"
+
+	NamespacesFromAll  = "All"
+	NamespacesFromSame = "Same"
+
+	DefaultRedirectStatusCode = 302
+	DefaultBackendWeight      = 1
+	DefaultPathPrefix         = "/"
+)
+
+type GatewayClass struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              GatewayClassSpec `json:"spec"`
+}
+
+type GatewayClassSpec struct {
+	ControllerName string `json:"controllerName"`
+}
+
+type Gateway struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              GatewaySpec `json:"spec"`
+}
+
+type GatewaySpec struct {
+	GatewayClassName string     `json:"gatewayClassName"`
+	Listeners        []Listener `json:"listeners"`
+}
+
+type Listener struct {
+	Name          string         `json:"name"`
+	Hostname      *string        `json:"hostname,omitempty"`
+	Port          int32          `json:"port"`
+	Protocol      string         `json:"protocol"`
+	TLS           *GatewayTLS    `json:"tls,omitempty"`
+	AllowedRoutes *AllowedRoutes `json:"allowedRoutes,omitempty"`
+}
+
+type GatewayTLS struct {
+	Mode            *string           `json:"mode,omitempty"`
+	CertificateRefs []SecretReference `json:"certificateRefs,omitempty"`
+}
+
+type SecretReference struct {
+	Name      string  `json:"name"`
+	Namespace *string `json:"namespace,omitempty"`
+}
+
+type AllowedRoutes struct {
+	Namespaces *RouteNamespaces `json:"namespaces,omitempty"`
+}
+
+type RouteNamespaces struct {
+	From *string `json:"from,omitempty"`
+}
+
+type HTTPRoute struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              HTTPRouteSpec `json:"spec"`
+}
+
+type HTTPRouteSpec struct {
+	ParentRefs []ParentReference `json:"parentRefs,omitempty"`
+	Hostnames  []string          `json:"hostnames,omitempty"`
+	Rules      []HTTPRouteRule   `json:"rules,omitempty"`
+}
+
+type ParentReference struct {
+	Group       *string `json:"group,omitempty"`
+	Kind        *string `json:"kind,omitempty"`
+	Namespace   *string `json:"namespace,omitempty"`
+	Name        string  `json:"name"`
+	SectionName *string `json:"sectionName,omitempty"`
+	Port        *int32  `json:"port,omitempty"`
+}
+
+type HTTPRouteRule struct {
+	Matches     []HTTPRouteMatch  `json:"matches,omitempty"`
+	Filters     []HTTPRouteFilter `json:"filters,omitempty"`
+	BackendRefs []HTTPBackendRef  `json:"backendRefs,omitempty"`
+}
+
+type HTTPRouteMatch struct {
+	Path        *HTTPPathMatch        `json:"path,omitempty"`
+	Headers     []HTTPHeaderMatch     `json:"headers,omitempty"`
+	QueryParams []HTTPQueryParamMatch `json:"queryParams,omitempty"`
+	Method      *string               `json:"method,omitempty"`
+}
+
+type HTTPPathMatch struct {
+	Type  *string `json:"type,omitempty"`
+	Value *string `json:"value,omitempty"`
+}
+
+type HTTPHeaderMatch struct {
+	Type  *string `json:"type,omitempty"`
+	Name  string  `json:"name"`
+	Value string  `json:"value"`
+}
+
+type HTTPQueryParamMatch struct {
+	Type  *string `json:"type,omitempty"`
+	Name  string  `json:"name"`
+	Value string  `json:"value"`
+}
+
+type HTTPRouteFilter struct {
+	Type                  string                   `json:"type"`
+	RequestHeaderModifier *HTTPHeaderFilter        `json:"requestHeaderModifier,omitempty"`
+	RequestRedirect       *HTTPRequestRedirectSpec `json:"requestRedirect,omitempty"`
+	URLRewrite            *HTTPURLRewriteFilter    `json:"urlRewrite,omitempty"`
+}
+
+type HTTPHeaderFilter struct {
+	Set    []HTTPHeader `json:"set,omitempty"`
+	Add    []HTTPHeader `json:"add,omitempty"`
+	Remove []string     `json:"remove,omitempty"`
+}
+
+type HTTPHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type HTTPRequestRedirectSpec struct {
+	Scheme     *string           `json:"scheme,omitempty"`
+	Hostname   *string           `json:"hostname,omitempty"`
+	Path       *HTTPPathModifier `json:"path,omitempty"`
+	Port       *int32            `json:"port,omitempty"`
+	StatusCode *int              `json:"statusCode,omitempty"`
+}
+
+type HTTPURLRewriteFilter struct {
+	Hostname *string           `json:"hostname,omitempty"`
+	Path     *HTTPPathModifier `json:"path,omitempty"`
+}
+
+type HTTPPathModifier struct {
+	Type               string  `json:"type"`
+	ReplaceFullPath    *string `json:"replaceFullPath,omitempty"`
+	ReplacePrefixMatch *string `json:"replacePrefixMatch,omitempty"`
+}
+
+type HTTPBackendRef struct {
+	Group     *string `json:"group,omitempty"`
+	Kind      *string `json:"kind,omitempty"`
+	Name      string  `json:"name"`
+	Namespace *string `json:"namespace,omitempty"`
+	Port      *int32  `json:"port,omitempty"`
+	Weight    *int32  `json:"weight,omitempty"`
+}
+
+// FromUnstructured converts an unstructured Gateway API object into one of the types above.
+func FromUnstructured(obj map[string]interface{}, into interface{}) error {
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(obj, into)
+}
@@ -0,0 +1,225 @@
+package health
+
+import (
+	"context"
+	"io"
+	"k8s.io/klog/v2"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Check is how the endpoints of the backends of an ingress are probed, an endpoint turns unhealthy after Unhealthy
+// failed probes in a row and healthy again after Healthy successful ones.
+type Check struct {
+	Path      string
+	StatusMin int
+	StatusMax int
+	Timeout   time.Duration
+	Interval  time.Duration
+	Healthy   int
+	Unhealthy int
+}
+
+// Target is an endpoint of the Service of a backend.
+type Target struct {
+	Service string
+	Address string
+}
+
+// Notify is called when a target turns healthy or unhealthy.
+type Notify func(t Target, healthy bool)
+
+type state struct {
+	healthy   bool
+	successes int
+	failures  int
+}
+
+// update counts a probe result and reports whether the target changed state.
+func (s *state) update(ok bool, c Check) bool {
+	if ok {
+		s.successes, s.failures = s.successes+1, 0
+	} else {
+		s.successes, s.failures = 0, s.failures+1
+	}
+
+	switch {
+	case !s.healthy && s.successes >= c.Healthy:
+		s.healthy = true
+		return true
+	case s.healthy && s.failures >= c.Unhealthy:
+		s.healthy = false
+		return true
+	}
+
+	return false
+}
+
+type owner struct {
+	check   Check
+	targets []Target
+	notify  Notify
+	states  map[Target]*state
+	stop    chan struct{}
+}
+
+// owners are the probed ingresses by namespace/name, every state change happens under mux.
+var (
+	mux    sync.RWMutex
+	owners = make(map[string]*owner)
+)
+
+var client = &http.Client{
+	Transport: &http.Transport{DisableKeepAlives: true},
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// Set probes targets for key every check.Interval until Delete. The probing only restarts when check or targets
+// changed, the targets probed before keep their state and the new ones start healthy.
+func Set(key string, check Check, targets []Target, notify Notify) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	old, ok := owners[key]
+	if ok {
+		old.notify = notify
+		if old.check == check && slices.Equal(old.targets, targets) {
+			return
+		}
+		close(old.stop)
+	}
+
+	o := &owner{
+		check:   check,
+		targets: targets,
+		notify:  notify,
+		states:  make(map[Target]*state),
+		stop:    make(chan struct{}),
+	}
+	for _, t := range targets {
+		if s, found := old.stateOf(t); found {
+			o.states[t] = s
+			continue
+		}
+		o.states[t] = &state{healthy: true}
+	}
+
+	owners[key] = o
+	go o.run()
+}
+
+// Delete stops probing the targets of key.
+func Delete(key string) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if o, ok := owners[key]; ok {
+		close(o.stop)
+		delete(owners, key)
+	}
+}
+
+// Healthy reports whether the endpoint at address of key passes its checks, endpoints not probed are healthy.
+func Healthy(key, address string) bool {
+	mux.RLock()
+	defer mux.RUnlock()
+
+	o, ok := owners[key]
+	if !ok {
+		return true
+	}
+
+	for t, s := range o.states {
+		if t.Address == address {
+			return s.healthy
+		}
+	}
+
+	return true
+}
+
+func (o *owner) stateOf(t Target) (*state, bool) {
+	if o == nil {
+		return nil, false
+	}
+
+	s, ok := o.states[t]
+	return s, ok
+}
+
+func (o *owner) run() {
+	ticker := time.NewTicker(o.check.Interval)
+	defer ticker.Stop()
+
+	for {
+		o.probeAll()
+
+		select {
+		case <-o.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (o *owner) probeAll() {
+	results := make([]bool, len(o.targets))
+
+	var wg sync.WaitGroup
+	for i, t := range o.targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = probe(o.check, t.Address)
+		}()
+	}
+	wg.Wait()
+
+	mux.Lock()
+	select {
+	case <-o.stop:
+		mux.Unlock()
+		return
+	default:
+	}
+
+	changed := make(map[Target]bool)
+	for i, t := range o.targets {
+		if s := o.states[t]; s.update(results[i], o.check) {
+			changed[t] = s.healthy
+		}
+	}
+	notify := o.notify
+	mux.Unlock()
+
+	for t, healthy := range changed {
+		notify(t, healthy)
+	}
+}
+
+func probe(c Check, address string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), c.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+address+c.Path, nil)
+	if err != nil {
+		klog.ErrorS(err, "fail to build the health check request of "+address)
+		return false
+	}
+	req.Header.Set("User-Agent", "ingress-nginx-kubebuilder-health-check")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		klog.V(4).InfoS("health check failed", "address", address, "path", c.Path, "error", err)
+		return false
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	return resp.StatusCode >= c.StatusMin && resp.StatusCode <= c.StatusMax
+}
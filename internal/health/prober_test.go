@@ -0,0 +1,179 @@
+package health
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStateUpdate(t *testing.T) {
+	check := Check{Healthy: 2, Unhealthy: 3}
+
+	tests := []struct {
+		name    string
+		healthy bool
+		probes  []bool
+		// changes are the probes, by index, expected to change the state
+		changes []int
+		want    bool
+	}{
+		{name: "healthy stays healthy", healthy: true, probes: []bool{true, true, true}, want: true},
+		{name: "failures below threshold", healthy: true, probes: []bool{false, false, true, false, false}, want: true},
+		{name: "turns unhealthy", healthy: true, probes: []bool{false, false, false, false}, changes: []int{2}},
+		{name: "success below threshold", probes: []bool{true, false, true}},
+		{name: "turns healthy", probes: []bool{true, true, true}, changes: []int{1}, want: true},
+		{name: "flaps", healthy: true, probes: []bool{false, false, false, true, true, false}, changes: []int{2, 4}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &state{healthy: tt.healthy}
+
+			var changes []int
+			for i, ok := range tt.probes {
+				if s.update(ok, check) {
+					changes = append(changes, i)
+				}
+			}
+
+			if len(changes) != len(tt.changes) {
+				t.Fatalf("update() changed at %v, want %v", changes, tt.changes)
+			}
+			for i := range changes {
+				if changes[i] != tt.changes[i] {
+					t.Fatalf("update() changed at %v, want %v", changes, tt.changes)
+				}
+			}
+			if s.healthy != tt.want {
+				t.Errorf("healthy = %v, want %v", s.healthy, tt.want)
+			}
+		})
+	}
+}
+
+func TestProbe(t *testing.T) {
+	var status atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		want   bool
+	}{
+		{name: "ok", path: "/healthz", status: http.StatusOK, want: true},
+		{name: "top of range", path: "/healthz", status: 399, want: true},
+		{name: "redirect is not followed", path: "/healthz", status: http.StatusFound, want: true},
+		{name: "error", path: "/healthz", status: http.StatusInternalServerError},
+		{name: "timeout", path: "/slow", status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status.Store(int32(tt.status))
+			c := Check{Path: tt.path, StatusMin: 200, StatusMax: 399, Timeout: 50 * time.Millisecond}
+			if got := probe(c, srv.Listener.Addr().String()); got != tt.want {
+				t.Errorf("probe() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if probe(Check{Path: "/", StatusMin: 200, StatusMax: 399, Timeout: 50 * time.Millisecond}, "127.0.0.1:1") {
+		t.Error("probe() of a closed port = true, want false")
+	}
+}
+
+func TestSetHealthyDelete(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	passing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer passing.Close()
+
+	const key = "default/app"
+	check := Check{Path: "/", StatusMin: 200, StatusMax: 399, Timeout: time.Second, Interval: 10 * time.Millisecond, Healthy: 1, Unhealthy: 2}
+	a := Target{Service: "app", Address: failing.Listener.Addr().String()}
+	b := Target{Service: "app", Address: passing.Listener.Addr().String()}
+
+	notified := make(chan Target, 16)
+	notify := func(t Target, healthy bool) {
+		if !healthy {
+			notified <- t
+		}
+	}
+
+	if !Healthy(key, a.Address) {
+		t.Fatal("Healthy() of a target not probed = false, want true")
+	}
+
+	Set(key, check, []Target{a}, notify)
+	defer Delete(key)
+
+	select {
+	case got := <-notified:
+		if got != a {
+			t.Fatalf("notified %v, want %v", got, a)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the failing target was not reported unhealthy")
+	}
+
+	if Healthy(key, a.Address) {
+		t.Error("Healthy() of the failing target = true, want false")
+	}
+
+	first := ownerOf(key)
+	Set(key, check, []Target{a}, notify)
+	if ownerOf(key) != first {
+		t.Error("Set() with the same check and targets restarted the probing")
+	}
+
+	Set(key, check, []Target{a, b}, notify)
+	second := ownerOf(key)
+	if second == first {
+		t.Fatal("Set() with new targets did not restart the probing")
+	}
+	select {
+	case <-first.stop:
+	default:
+		t.Error("Set() with new targets did not stop the previous probing")
+	}
+	if Healthy(key, a.Address) {
+		t.Error("Healthy() of the failing target after the restart = true, want the previous state")
+	}
+	if !Healthy(key, b.Address) {
+		t.Error("Healthy() of the new target = false, want true")
+	}
+
+	Delete(key)
+	if ownerOf(key) != nil {
+		t.Error("Delete() kept the owner")
+	}
+	select {
+	case <-second.stop:
+	default:
+		t.Error("Delete() did not stop the probing")
+	}
+	if !Healthy(key, a.Address) {
+		t.Error("Healthy() after Delete() = false, want true")
+	}
+}
+
+func ownerOf(key string) *owner {
+	mux.RLock()
+	defer mux.RUnlock()
+
+	return owners[key]
+}
@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"time"
+)
+
+// sslCertificateExpiry is registered in the controller-runtime registry, it is served with the other controller metrics.
+var sslCertificateExpiry = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "ingress_ssl_certificate_expiry_seconds",
+	Help: "Expiry time of the certificate served for a host, in seconds since the epoch.",
+}, []string{"namespace", "ingress", "host"})
+
+// backendEndpointHealthy is 1 for the health checked endpoints passing their checks and 0 for the failing ones.
+var backendEndpointHealthy = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "ingress_backend_endpoint_healthy",
+	Help: "Whether a health checked endpoint of a backend passes its checks.",
+}, []string{"namespace", "ingress", "service", "endpoint"})
+
+func init() {
+	metrics.Registry.MustRegister(sslCertificateExpiry, backendEndpointHealthy)
+}
+
+// SetCertificateExpiry records the expiry time of the certificate served for host.
+func SetCertificateExpiry(namespace, ingress, host string, notAfter time.Time) {
+	sslCertificateExpiry.WithLabelValues(namespace, ingress, host).Set(float64(notAfter.Unix()))
+}
+
+// SetEndpointHealth records whether the endpoint of service passes the health checks of an ingress.
+func SetEndpointHealth(namespace, ingress, service, endpoint string, healthy bool) {
+	var v float64
+	if healthy {
+		v = 1
+	}
+
+	backendEndpointHealthy.WithLabelValues(namespace, ingress, service, endpoint).Set(v)
+}
+
+// DeleteEndpointHealth drops the health series of every endpoint of an ingress.
+func DeleteEndpointHealth(namespace, ingress string) {
+	backendEndpointHealthy.DeletePartialMatch(prometheus.Labels{"namespace": namespace, "ingress": ingress})
+}
+
+// DeleteCertificateExpiry drops the expiry series of every host of an ingress.
+func DeleteCertificateExpiry(namespace, ingress string) {
+	sslCertificateExpiry.DeletePartialMatch(prometheus.Labels{"namespace": namespace, "ingress": ingress})
+}
+
+// DeleteIngress drops the series of every host and endpoint of an ingress.
+func DeleteIngress(namespace, ingress string) {
+	DeleteCertificateExpiry(namespace, ingress)
+	DeleteEndpointHealth(namespace, ingress)
+}
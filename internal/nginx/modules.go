@@ -0,0 +1,33 @@
+package nginx
+
+import (
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/config"
+	cmd2 "github.com/ingoxx/ingress-nginx-kubebuilder/pkg/utils/cmd"
+	"k8s.io/klog/v2"
+	"strings"
+	"sync"
+)
+
+var (
+	buildOnce sync.Once
+	buildInfo string
+)
+
+// hasModule reports whether nginx -V lists a configure argument containing name, e.g. a module built in.
+func hasModule(name string) bool {
+	buildOnce.Do(func() {
+		out, err := cmd2.NewCommand(config.Bin, false, []string{"-V"}).CombinedOutput()
+		if err != nil {
+			klog.ErrorS(err, "fail to read the build information of nginx")
+		}
+		buildInfo = string(out)
+	})
+
+	return strings.Contains(buildInfo, name)
+}
+
+// ModSecurityAvailable reports whether nginx is built with the ModSecurity connector or loads it from
+// --modsecurity-module.
+func ModSecurityAvailable() bool {
+	return config.ModSecurityModulePath() != "" || hasModule("modsecurity")
+}
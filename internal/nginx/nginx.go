@@ -2,6 +2,7 @@ package nginx
 
 import (
 	"fmt"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/certstore"
 	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/config"
 	cmd2 "github.com/ingoxx/ingress-nginx-kubebuilder/pkg/utils/cmd"
 	"github.com/ingoxx/ingress-nginx-kubebuilder/pkg/utils/file"
@@ -90,7 +91,7 @@ func reload(name string) error {
 		isFirstReload = true
 	}
 
-	if err := cmd2.NewCommand(config.Bin, true, []string{"-t"}).Execute(); err != nil {
+	if err := cmd2.NewCommand(config.Bin, true, []string{"-t", "-c", config.MainConf}).Execute(); err != nil {
 		klog.ErrorS(err, fmt.Sprintf("nginx configuration: %s file verification fails, pls check", productConf))
 		if !isFirstReload {
 			if err := rolloutConf(backupFile, productConf); err != nil {
@@ -108,11 +109,14 @@ func reload(name string) error {
 		return err
 	}
 
+	// nginx has loaded the certificates, the ones the configuration no longer refers to can go
+	certstore.Prune()
+
 	return nil
 }
 
 func reloadIfWatchFileCurd() {
-	if err := cmd2.NewCommand(config.Bin, true, []string{"-t"}).Execute(); err != nil {
+	if err := cmd2.NewCommand(config.Bin, true, []string{"-t", "-c", config.MainConf}).Execute(); err != nil {
 		klog.ErrorS(err, "failed to successfully reload nginx upon detecting file changes")
 		return
 	}
@@ -120,6 +124,8 @@ func reloadIfWatchFileCurd() {
 	if err := gracefulRestart(); err != nil {
 		return
 	}
+
+	certstore.Prune()
 }
 
 func isRunning() bool {
@@ -162,6 +168,12 @@ func gracefulRestart() error {
 
 func Start() {
 	klog.Info("start nginx")
+	for _, dir := range []string{config.StreamConfDir, config.AcmeConfDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			klog.Fatal(fmt.Sprintf("fail to create %s, error %v", dir, err))
+		}
+	}
+
 	var done = make(chan struct{})
 	go func() {
 		stopSingle := time.NewTimer(time.Duration(10) * time.Second)
@@ -197,4 +209,12 @@ func dirWatcher() {
 	if _, err := file.NewFileWatcher(config.ConfDir, reloadIfWatchFileCurd); err != nil {
 		klog.Fatal(fmt.Sprintf("fail to watch %s, error %v", config.ConfDir, err))
 	}
+
+	if _, err := file.NewFileWatcher(config.StreamConfDir, reloadIfWatchFileCurd); err != nil {
+		klog.Fatal(fmt.Sprintf("fail to watch %s, error %v", config.StreamConfDir, err))
+	}
+
+	if _, err := file.NewFileWatcher(config.AcmeConfDir, reloadIfWatchFileCurd); err != nil {
+		klog.Fatal(fmt.Sprintf("fail to watch %s, error %v", config.AcmeConfDir, err))
+	}
 }
@@ -0,0 +1,251 @@
+package stream
+
+import (
+	"fmt"
+	ingressv1 "github.com/ingoxx/ingress-nginx-kubebuilder/api/v1"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/config"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/nginx"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/pkg/utils/template_nginx"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+const (
+	httpsUpstream       = "stream-https"
+	passthroughUpstream = "stream-ssl-passthrough"
+	passthroughName     = "ssl-passthrough"
+	localAddress        = "127.0.0.1"
+)
+
+// Listener is one entry of the stream {} context, e.g. a TransportServer.
+type Listener struct {
+	// Name identifies the owner of the listener, it is used to name the upstream and in conflict messages
+	Name string
+	Port int32
+	UDP  bool
+	// Passthrough listeners with a Host are routed by the SNI of the client hello and can share a port
+	Passthrough bool
+	Host        string
+	Tls         ingressv1.SSLCert
+	Servers     []ingressv1.UpstreamServer
+}
+
+// store keeps the listeners of every source of the stream context, they all end up in the same conf
+// because a port can be claimed by any of them.
+type store struct {
+	mux         sync.Mutex
+	transport   []Listener
+	passthrough map[string][]Listener
+}
+
+var st = &store{
+	passthrough: make(map[string][]Listener),
+}
+
+// SetTransportServers replaces the listeners of the TransportServers, in the order they claim their ports.
+func SetTransportServers(listeners []Listener) {
+	st.mux.Lock()
+	defer st.mux.Unlock()
+
+	st.transport = listeners
+}
+
+// SetPassthrough replaces the ssl passthrough hosts of the ingress identified by key.
+func SetPassthrough(key string, listeners []Listener) {
+	st.mux.Lock()
+	defer st.mux.Unlock()
+
+	st.passthrough[key] = listeners
+}
+
+// DeletePassthrough drops the ssl passthrough hosts of key, it reports whether key had any.
+func DeletePassthrough(key string) bool {
+	st.mux.Lock()
+	defer st.mux.Unlock()
+
+	_, ok := st.passthrough[key]
+	delete(st.passthrough, key)
+
+	return ok
+}
+
+// Sync renders every listener into the stream conf and reloads nginx. The listeners that could not be
+// rendered, e.g. because their port is taken, are returned keyed by their Name.
+func Sync() (map[string]error, error) {
+	st.mux.Lock()
+	defer st.mux.Unlock()
+
+	cfg, rejected := Build(st.transport)
+	if config.SSLPassthrough {
+		keys := make([]string, 0, len(st.passthrough))
+		for k := range st.passthrough {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		var listeners []Listener
+		for _, k := range keys {
+			listeners = append(listeners, st.passthrough[k]...)
+		}
+
+		addPassthrough(cfg, listeners, rejected)
+	}
+
+	return rejected, render(cfg, true)
+}
+
+// Prepare writes the stream conf before nginx starts, so the ssl passthrough listener owns the https port from the beginning.
+func Prepare() error {
+	if err := os.MkdirAll(config.StreamConfDir, 0755); err != nil {
+		return err
+	}
+
+	cfg, rejected := Build(nil)
+	if config.SSLPassthrough {
+		addPassthrough(cfg, nil, rejected)
+	}
+
+	return render(cfg, false)
+}
+
+// Build groups the listeners by port. Listeners are placed in the given order, a listener that can't share
+// the port of one placed before it is left out and reported in the returned map, keyed by its Name.
+func Build(listeners []Listener) (*ingressv1.StreamConfiguration, map[string]error) {
+	cfg := new(ingressv1.StreamConfiguration)
+	rejected := make(map[string]error)
+	ports := make(map[string]*ingressv1.StreamServer)
+
+	for _, l := range listeners {
+		key := fmt.Sprintf("%d/%t", l.Port, l.UDP)
+		upstream := "stream-" + l.Name
+		routed := l.Passthrough && l.Host != ""
+
+		if s, ok := ports[key]; ok {
+			if !routed || len(s.Routes) == 0 {
+				rejected[l.Name] = fmt.Errorf("port %d is already used by %s", l.Port, s.Name)
+				continue
+			}
+
+			if owner := routeOwner(s, l.Host); owner != "" {
+				rejected[l.Name] = fmt.Errorf("host %s on port %d is already used by %s", l.Host, l.Port, owner)
+				continue
+			}
+
+			s.Routes = append(s.Routes, &ingressv1.StreamRoute{Host: l.Host, UpstreamName: upstream})
+			cfg.Upstreams = append(cfg.Upstreams, &ingressv1.Upstream{Name: upstream, Servers: l.Servers})
+			continue
+		}
+
+		s := &ingressv1.StreamServer{
+			Name: l.Name,
+			Port: l.Port,
+			UDP:  l.UDP,
+		}
+
+		if routed {
+			s.Routes = []*ingressv1.StreamRoute{{Host: l.Host, UpstreamName: upstream}}
+		} else {
+			s.UpstreamName = upstream
+			if !l.Passthrough {
+				s.Tls = l.Tls
+			}
+		}
+
+		ports[key] = s
+		cfg.Servers = append(cfg.Servers, s)
+		cfg.Upstreams = append(cfg.Upstreams, &ingressv1.Upstream{Name: upstream, Servers: l.Servers})
+	}
+
+	return cfg, rejected
+}
+
+// addPassthrough puts the front listener on the https port. Connections whose SNI is a passthrough host go through
+// an internal hop routing them to their backend, the others go to the https servers of the http context. The front
+// listener sends the PROXY protocol so the http context still sees the client address, the hop strips it because
+// the passthrough backends don't expect it. With use-proxy-protocol the front listener also expects it from the load
+// balancer.
+func addPassthrough(cfg *ingressv1.StreamConfiguration, listeners []Listener, rejected map[string]error) {
+	front := &ingressv1.StreamServer{
+		Name:                passthroughName,
+		Port:                config.HTTPSPort,
+		DefaultUpstream:     httpsUpstream,
+		ProxyProtocol:       true,
+		AcceptProxyProtocol: config.UseProxyProtocol,
+	}
+
+	hop := &ingressv1.StreamServer{
+		Name:                passthroughName,
+		Address:             localAddress,
+		Port:                config.SSLPassthroughHopPort,
+		AcceptProxyProtocol: true,
+	}
+
+	for _, l := range listeners {
+		if owner := routeOwner(hop, l.Host); owner != "" {
+			rejected[l.Name] = fmt.Errorf("ssl passthrough host %s is already used by %s", l.Host, owner)
+			continue
+		}
+
+		upstream := "stream-" + l.Name
+		hop.Routes = append(hop.Routes, &ingressv1.StreamRoute{Host: l.Host, UpstreamName: upstream})
+		front.Routes = append(front.Routes, &ingressv1.StreamRoute{Host: l.Host, UpstreamName: passthroughUpstream})
+		cfg.Upstreams = append(cfg.Upstreams, &ingressv1.Upstream{Name: upstream, Servers: l.Servers})
+	}
+
+	cfg.Upstreams = append(cfg.Upstreams, &ingressv1.Upstream{
+		Name:    httpsUpstream,
+		Servers: []ingressv1.UpstreamServer{{Address: fmt.Sprintf("%s:%d", localAddress, config.SSLPassthroughPort)}},
+	})
+	cfg.Servers = append(cfg.Servers, front)
+
+	if len(hop.Routes) > 0 {
+		cfg.Upstreams = append(cfg.Upstreams, &ingressv1.Upstream{
+			Name:    passthroughUpstream,
+			Servers: []ingressv1.UpstreamServer{{Address: fmt.Sprintf("%s:%d", localAddress, config.SSLPassthroughHopPort)}},
+		})
+		cfg.Servers = append(cfg.Servers, hop)
+	}
+}
+
+func routeOwner(s *ingressv1.StreamServer, host string) string {
+	for _, r := range s.Routes {
+		if r.Host == host {
+			return r.UpstreamName
+		}
+	}
+
+	return ""
+}
+
+func render(cfg *ingressv1.StreamConfiguration, reload bool) error {
+	name := filepath.Join(config.StreamConfDir, "stream")
+
+	if len(cfg.Servers) == 0 {
+		nginx.CleanConf(name + ".conf")
+		return nil
+	}
+
+	pr := &template_nginx.RenderTemplate{
+		GenerateName:       name,
+		RenderTemplateName: config.StreamTmpl,
+		MainTemplateName:   config.MainServerTmpl,
+	}
+
+	var data = struct {
+		Cfg *ingressv1.StreamConfiguration
+	}{
+		Cfg: cfg,
+	}
+
+	if err := pr.Render(data); err != nil {
+		return err
+	}
+
+	if !reload {
+		return os.Rename(name+"-test.conf", name+".conf")
+	}
+
+	return nginx.Reload(name)
+}
@@ -0,0 +1,141 @@
+package stream
+
+import (
+	ingressv1 "github.com/ingoxx/ingress-nginx-kubebuilder/api/v1"
+	"slices"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name      string
+		listeners []Listener
+		// servers maps the name of every rendered server to its route hosts
+		servers   map[string][]string
+		upstreams []string
+		rejected  []string
+	}{
+		{
+			name:      "plain port",
+			listeners: []Listener{{Name: "a", Port: 5432}},
+			servers:   map[string][]string{"a": nil},
+			upstreams: []string{"stream-a"},
+		},
+		{
+			name:      "port conflict",
+			listeners: []Listener{{Name: "a", Port: 5432}, {Name: "b", Port: 5432}},
+			servers:   map[string][]string{"a": nil},
+			upstreams: []string{"stream-a"},
+			rejected:  []string{"b"},
+		},
+		{
+			name:      "tcp and udp on the same port",
+			listeners: []Listener{{Name: "a", Port: 53}, {Name: "b", Port: 53, UDP: true}},
+			servers:   map[string][]string{"a": nil, "b": nil},
+			upstreams: []string{"stream-a", "stream-b"},
+		},
+		{
+			name: "sni routing shares the port",
+			listeners: []Listener{
+				{Name: "a", Port: 8443, Passthrough: true, Host: "a.example.com"},
+				{Name: "b", Port: 8443, Passthrough: true, Host: "b.example.com"},
+			},
+			servers:   map[string][]string{"a": {"a.example.com", "b.example.com"}},
+			upstreams: []string{"stream-a", "stream-b"},
+		},
+		{
+			name: "duplicate host",
+			listeners: []Listener{
+				{Name: "a", Port: 8443, Passthrough: true, Host: "a.example.com"},
+				{Name: "b", Port: 8443, Passthrough: true, Host: "a.example.com"},
+			},
+			servers:   map[string][]string{"a": {"a.example.com"}},
+			upstreams: []string{"stream-a"},
+			rejected:  []string{"b"},
+		},
+		{
+			name: "routed after plain",
+			listeners: []Listener{
+				{Name: "a", Port: 8443},
+				{Name: "b", Port: 8443, Passthrough: true, Host: "b.example.com"},
+			},
+			servers:   map[string][]string{"a": nil},
+			upstreams: []string{"stream-a"},
+			rejected:  []string{"b"},
+		},
+		{
+			name: "plain after routed",
+			listeners: []Listener{
+				{Name: "a", Port: 8443, Passthrough: true, Host: "a.example.com"},
+				{Name: "b", Port: 8443},
+			},
+			servers:   map[string][]string{"a": {"a.example.com"}},
+			upstreams: []string{"stream-a"},
+			rejected:  []string{"b"},
+		},
+		{
+			name: "passthrough without host",
+			listeners: []Listener{
+				{Name: "a", Port: 8443, Passthrough: true},
+				{Name: "b", Port: 8443, Passthrough: true, Host: "b.example.com"},
+			},
+			servers:   map[string][]string{"a": nil},
+			upstreams: []string{"stream-a"},
+			rejected:  []string{"b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, rejected := Build(tt.listeners)
+
+			if len(cfg.Servers) != len(tt.servers) {
+				t.Fatalf("Build() rendered %d servers, want %d", len(cfg.Servers), len(tt.servers))
+			}
+
+			for _, s := range cfg.Servers {
+				hosts, ok := tt.servers[s.Name]
+				if !ok {
+					t.Fatalf("Build() rendered unexpected server %s", s.Name)
+				}
+
+				if len(hosts) == 0 {
+					if s.UpstreamName != "stream-"+s.Name || len(s.Routes) != 0 {
+						t.Errorf("server %s: upstream = %q, routes = %d, want a plain proxy to stream-%s", s.Name, s.UpstreamName, len(s.Routes), s.Name)
+					}
+					continue
+				}
+
+				if got := routeHosts(s); !slices.Equal(got, hosts) {
+					t.Errorf("server %s: routes = %v, want %v", s.Name, got, hosts)
+				}
+			}
+
+			var upstreams []string
+			for _, u := range cfg.Upstreams {
+				upstreams = append(upstreams, u.Name)
+			}
+			if !slices.Equal(upstreams, tt.upstreams) {
+				t.Errorf("Build() upstreams = %v, want %v", upstreams, tt.upstreams)
+			}
+
+			if len(rejected) != len(tt.rejected) {
+				t.Errorf("Build() rejected = %v, want %v", rejected, tt.rejected)
+			}
+			for _, name := range tt.rejected {
+				if rejected[name] == nil {
+					t.Errorf("Build() did not reject %s", name)
+				}
+			}
+		})
+	}
+}
+
+func routeHosts(s *ingressv1.StreamServer) []string {
+	var hosts []string
+	for _, r := range s.Routes {
+		hosts = append(hosts, r.Host)
+	}
+
+	return hosts
+}
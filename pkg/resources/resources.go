@@ -2,8 +2,11 @@ package resources
 
 import (
 	"context"
+	"fmt"
 	ingressv1 "github.com/ingoxx/ingress-nginx-kubebuilder/api/v1"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/certmanager"
 	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/annotations/resolver"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/config"
 	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/controller/store"
 	kerrs "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -18,6 +21,17 @@ import (
 	"sort"
 )
 
+const (
+	ingressClassAnnotation = "kubernetes.io/ingress.class"
+	// acmeSolverLabel marks the ingresses cert-manager creates to answer http01 challenges
+	acmeSolverLabel = "acme.cert-manager.io/http01-solver"
+)
+
+var (
+	certGVR   = schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+	issuerGVR = schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "issuers"}
+)
+
 type Resources struct {
 	dynamicClientSet *dynamic.DynamicClient
 	client           client.Client
@@ -27,27 +41,51 @@ type Resources struct {
 }
 
 // ReconcileResource If the spec.tls field is not empty, the certificate and issuer resources here will not be created.
-func ReconcileResource(store store.Storer) error {
+// It reports whether the certificate of the ingress is Ready and its secret can be served.
+func ReconcileResource(store store.Storer, cfg certmanager.Config) (bool, error) {
 	ctlInfo := store.ReconcilerInfo()
 	r := NewResource(ctlInfo)
 
-	if len(r.ingress.Spec.Rules) == 0 {
-		return nil
+	if !ManagesCertificate(r.ingress, cfg) {
+		return false, nil
 	}
 
-	if len(r.ingress.Spec.TLS) == 0 {
+	kind, name := r.issuerRef(cfg)
+	if kind == "" {
+		kind, name = "Issuer", r.ingress.Name+"-issuer"
 		if err := r.reconcileIssuer(); err != nil {
 			klog.ErrorS(err, "fail to reconcile issuer resource")
-			return err
+			return false, err
 		}
+	}
+
+	ready, err := r.reconcileCert(ctlInfo.IngressInfos, kind, name, cfg)
+	if err != nil {
+		klog.ErrorS(err, "fail to reconcile certificate resource")
+		return false, err
+	}
+
+	return ready, nil
+}
+
+// ManagesCertificate reports whether the certificate of ing is requested from cert-manager by the controller.
+func ManagesCertificate(ing *ingressv1.Ingress, cfg certmanager.Config) bool {
+	if len(ing.Spec.TLS) > 0 || cfg.Disable || IsAcmeSolver(ing) {
+		return false
+	}
 
-		if err := r.reconcileCert(ctlInfo.IngressInfos); err != nil {
-			klog.ErrorS(err, "fail to reconcile certificate resource")
-			return err
+	for _, r := range ing.Spec.Rules {
+		if r.Host != "" {
+			return true
 		}
 	}
 
-	return nil
+	return false
+}
+
+// IsAcmeSolver reports whether ing is created by cert-manager to answer acme http01 challenges.
+func IsAcmeSolver(ing *ingressv1.Ingress) bool {
+	return ing.GetLabels()[acmeSolverLabel] != ""
 }
 
 func NewResource(ctlInfo *store.IngressReconciler) *Resources {
@@ -60,8 +98,59 @@ func NewResource(ctlInfo *store.IngressReconciler) *Resources {
 	}
 }
 
-func (t *Resources) reconcileCert(rr resolver.Resolver) error {
-	certGVK := schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+// issuerRef returns the issuer selected by the annotations or the global default, empty when the ingress
+// gets an issuer of its own.
+func (t *Resources) issuerRef(cfg certmanager.Config) (string, string) {
+	switch {
+	case cfg.Issuer != "":
+		return "Issuer", cfg.Issuer
+	case cfg.ClusterIssuer != "":
+		return "ClusterIssuer", cfg.ClusterIssuer
+	case config.DefaultCertClusterIssuer != "":
+		return "ClusterIssuer", config.DefaultCertClusterIssuer
+	case config.DefaultCertIssuer != "":
+		return "Issuer", config.DefaultCertIssuer
+	}
+
+	return "", ""
+}
+
+func (t *Resources) certSpec(rr resolver.Resolver, kind, name string, cfg certmanager.Config) map[string]interface{} {
+	hosts := rr.GetHostName()
+	sort.Strings(hosts)
+
+	// a rule without host has no name to certify, wildcard hosts like *.example.com are requested as they are
+	dnsNames := make([]interface{}, 0, len(hosts))
+	for i, h := range hosts {
+		if h == "" || i > 0 && hosts[i-1] == h {
+			continue
+		}
+		dnsNames = append(dnsNames, h)
+	}
+
+	spec := map[string]interface{}{
+		"dnsNames": dnsNames,
+		"issuerRef": map[string]interface{}{
+			"group": "cert-manager.io",
+			"kind":  kind,
+			"name":  name,
+		},
+		"secretName": t.ingress.Name + "-secret",
+	}
+
+	if cfg.Duration != "" {
+		spec["duration"] = cfg.Duration
+	}
+
+	if cfg.RenewBefore != "" {
+		spec["renewBefore"] = cfg.RenewBefore
+	}
+
+	return spec
+}
+
+func (t *Resources) reconcileCert(rr resolver.Resolver, kind, name string, cfg certmanager.Config) (bool, error) {
+	certName := t.ingress.Name + "-cert"
 	certificate := &unstructured.Unstructured{
 		Object: map[string]interface{}{
 			"apiVersion": "cert-manager.io/v1",
@@ -69,79 +158,155 @@ func (t *Resources) reconcileCert(rr resolver.Resolver) error {
 		},
 	}
 
-	if err := t.client.Get(t.ctx, types.NamespacedName{Name: t.ingress.Name + "-cert", Namespace: t.ingress.Namespace}, certificate); err != nil {
+	spec := t.certSpec(rr, kind, name, cfg)
+
+	if err := t.client.Get(t.ctx, types.NamespacedName{Name: certName, Namespace: t.ingress.Namespace}, certificate); err != nil {
 		if kerrs.IsNotFound(err) {
-			hosts := rr.GetHostName()
 			createCert := &unstructured.Unstructured{
 				Object: map[string]interface{}{
 					"apiVersion": "cert-manager.io/v1",
 					"kind":       "Certificate",
 					"metadata": map[string]interface{}{
-						"name":      t.ingress.Name + "-cert",
+						"name":      certName,
 						"namespace": t.ingress.Namespace,
 					},
-					"spec": map[string]interface{}{
-						"dnsNames": hosts,
-						"issuerRef": map[string]interface{}{
-							"kind": "Issuer",
-							"name": t.ingress.Name + "-issuer",
-						},
-						"secretName": t.ingress.Name + "-secret",
-					},
+					"spec": spec,
 				},
 			}
-			_, err = t.dynamicClientSet.Resource(certGVK).Namespace(t.ingress.Namespace).Create(context.Background(), createCert, metav1.CreateOptions{})
+			_, err = t.dynamicClientSet.Resource(certGVR).Namespace(t.ingress.Namespace).Create(context.Background(), createCert, metav1.CreateOptions{})
 			if err != nil {
-				return err
+				return false, err
 			}
 
-			if _, err := rr.GetSecret(types.NamespacedName{Name: t.ingress.Name + "-secret", Namespace: t.ingress.Namespace}); err != nil {
-				return err
-			}
+			klog.Infof("create certificate: %s issued by %s: %s", certName, kind, name)
 
-			return nil
+			return false, nil
 		}
-		return err
+		return false, err
 	}
 
-	domains, found, err := unstructured.NestedStringSlice(certificate.Object, "spec", "dnsNames")
-	if !found || err != nil {
-		return err
+	current, _, err := unstructured.NestedMap(certificate.Object, "spec")
+	if err != nil {
+		return false, err
 	}
 
-	domainComparison := func(d1, d2 []string) bool {
-		sort.Strings(d1)
-		sort.Strings(d2)
+	if !certSpecEqual(current, spec) {
+		if err := unstructured.SetNestedMap(certificate.Object, spec, "spec"); err != nil {
+			return false, err
+		}
 
-		if reflect.DeepEqual(d1, d2) {
-			return true
+		if _, err := t.dynamicClientSet.Resource(certGVR).Namespace(t.ingress.Namespace).Update(context.TODO(), certificate, metav1.UpdateOptions{}); err != nil {
+			return false, err
 		}
 
-		return false
+		klog.Infof("update certificate: %s successfully", certName)
+
+		return false, nil
 	}
 
-	hosts := rr.GetHostName()
-	if isEqual := domainComparison(hosts, domains); !isEqual {
-		if err := unstructured.SetNestedStringSlice(certificate.Object, hosts, "spec", "dnsNames"); err != nil {
-			return err
+	return certReady(certificate), nil
+}
+
+// certSpecEqual compares the fields of the certificate spec managed by the controller.
+func certSpecEqual(current, desired map[string]interface{}) bool {
+	for _, k := range []string{"dnsNames", "issuerRef", "secretName", "duration", "renewBefore"} {
+		c, d := current[k], desired[k]
+		if k == "dnsNames" {
+			c, d = sortedStrings(c), sortedStrings(d)
 		}
 
-		if _, err := t.dynamicClientSet.Resource(certGVK).Namespace(t.ingress.Namespace).Update(context.TODO(), certificate, metav1.UpdateOptions{}); err != nil {
-			return err
+		if k == "issuerRef" {
+			if m, ok := c.(map[string]interface{}); ok && m["group"] == nil {
+				c = copyWith(m, "group", "cert-manager.io")
+			}
 		}
 
-		if _, err := rr.GetSecret(types.NamespacedName{Name: t.ingress.Name + "-secret", Namespace: t.ingress.Namespace}); err != nil {
-			return err
+		if !reflect.DeepEqual(c, d) {
+			return false
 		}
+	}
+
+	return true
+}
 
-		klog.Infof("update certificate: %s successfully", t.ingress.Name+"-cert")
+func sortedStrings(v interface{}) []string {
+	items, _ := v.([]interface{})
+	s := make([]string, 0, len(items))
+	for _, i := range items {
+		s = append(s, fmt.Sprint(i))
 	}
+	sort.Strings(s)
 
-	return nil
+	return s
+}
+
+func copyWith(m map[string]interface{}, key string, val interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(m)+1)
+	for k, v := range m {
+		c[k] = v
+	}
+	c[key] = val
+
+	return c
+}
+
+// certReady reports whether cert-manager marked the current generation of the certificate Ready.
+func certReady(certificate *unstructured.Unstructured) bool {
+	conditions, _, _ := unstructured.NestedSlice(certificate.Object, "status", "conditions")
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if !ok || cond["type"] != "Ready" {
+			continue
+		}
+
+		if gen, ok := cond["observedGeneration"].(int64); ok && gen != certificate.GetGeneration() {
+			return false
+		}
+
+		return cond["status"] == "True"
+	}
+
+	return false
+}
+
+// ingressClass is the class the ACME http01 solver ingress is created with, so this controller serves the challenge.
+func (t *Resources) ingressClass() string {
+	if val := t.ingress.GetAnnotations()[ingressClassAnnotation]; val != "" {
+		return val
+	}
+
+	return t.ingress.Spec.IngressClassName
+}
+
+func (t *Resources) issuerSpec() map[string]interface{} {
+	if config.ACMEEmail == "" {
+		return map[string]interface{}{
+			"selfSigned": map[string]interface{}{},
+		}
+	}
+
+	return map[string]interface{}{
+		"acme": map[string]interface{}{
+			"email":  config.ACMEEmail,
+			"server": config.ACMEServer,
+			"privateKeySecretRef": map[string]interface{}{
+				"name": t.ingress.Name + "-acme-account",
+			},
+			"solvers": []interface{}{
+				map[string]interface{}{
+					"http01": map[string]interface{}{
+						"ingress": map[string]interface{}{
+							"ingressClassName": t.ingressClass(),
+						},
+					},
+				},
+			},
+		},
+	}
 }
 
 func (t *Resources) reconcileIssuer() error {
-	issuerGVK := schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "issuers"}
+	issuerName := t.ingress.Name + "-issuer"
 	issuer := &unstructured.Unstructured{
 		Object: map[string]interface{}{
 			"apiVersion": "cert-manager.io/v1",
@@ -149,22 +314,22 @@ func (t *Resources) reconcileIssuer() error {
 		},
 	}
 
-	if err := t.client.Get(t.ctx, types.NamespacedName{Name: t.ingress.Name + "-issuer", Namespace: t.ingress.Namespace}, issuer); err != nil {
+	spec := t.issuerSpec()
+
+	if err := t.client.Get(t.ctx, types.NamespacedName{Name: issuerName, Namespace: t.ingress.Namespace}, issuer); err != nil {
 		if kerrs.IsNotFound(err) {
 			createIssuer := &unstructured.Unstructured{
 				Object: map[string]interface{}{
 					"apiVersion": "cert-manager.io/v1",
 					"kind":       "Issuer",
 					"metadata": map[string]interface{}{
-						"name":      t.ingress.Name + "-issuer",
+						"name":      issuerName,
 						"namespace": t.ingress.Namespace,
 					},
-					"spec": map[string]interface{}{
-						"selfSigned": map[string]interface{}{},
-					},
+					"spec": spec,
 				},
 			}
-			_, err = t.dynamicClientSet.Resource(issuerGVK).Namespace(t.ingress.Namespace).Create(context.Background(), createIssuer, metav1.CreateOptions{})
+			_, err = t.dynamicClientSet.Resource(issuerGVR).Namespace(t.ingress.Namespace).Create(context.Background(), createIssuer, metav1.CreateOptions{})
 			if err != nil {
 				return err
 			}
@@ -175,5 +340,27 @@ func (t *Resources) reconcileIssuer() error {
 		return err
 	}
 
+	current, _, err := unstructured.NestedMap(issuer.Object, "spec")
+	if err != nil {
+		return err
+	}
+
+	_, acme := current["acme"]
+	email, _, _ := unstructured.NestedString(current, "acme", "email")
+	server, _, _ := unstructured.NestedString(current, "acme", "server")
+	if !acme && config.ACMEEmail == "" || acme && email == config.ACMEEmail && server == config.ACMEServer {
+		return nil
+	}
+
+	if err := unstructured.SetNestedMap(issuer.Object, spec, "spec"); err != nil {
+		return err
+	}
+
+	if _, err := t.dynamicClientSet.Resource(issuerGVR).Namespace(t.ingress.Namespace).Update(context.TODO(), issuer, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+
+	klog.Infof("update issuer: %s successfully", issuerName)
+
 	return nil
 }
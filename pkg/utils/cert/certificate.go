@@ -1,5 +1,19 @@
 package utils
 
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"time"
+)
+
 func DecodeBase64(data map[string][]byte) (map[string][]byte, error) {
 	var parsed = make(map[string][]byte)
 	for k, v := range data {
@@ -7,3 +21,138 @@ func DecodeBase64(data map[string][]byte) (map[string][]byte, error) {
 	}
 	return parsed, nil
 }
+
+// Info is what the controller inspects in a served certificate, the leaf is the first certificate of the PEM bundle.
+type Info struct {
+	NotAfter time.Time
+	DNSNames []string
+	Issuer   string
+	// ChainComplete reports whether the bundle, with the system roots, verifies up to a trusted root
+	ChainComplete bool
+	leaf          *x509.Certificate
+}
+
+// ParseCertificate parses a PEM encoded tls.crt.
+func ParseCertificate(data []byte) (*Info, error) {
+	var chain []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		c, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, c)
+	}
+
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("no certificate found in pem data")
+	}
+
+	leaf := chain[0]
+
+	return &Info{
+		NotAfter:      leaf.NotAfter,
+		DNSNames:      leaf.DNSNames,
+		Issuer:        leaf.Issuer.String(),
+		ChainComplete: chainComplete(chain),
+		leaf:          leaf,
+	}, nil
+}
+
+// Covers reports whether the SANs of the certificate match host, wildcard SANs included.
+func (i *Info) Covers(host string) bool {
+	return i.leaf.VerifyHostname(host) == nil
+}
+
+// chainComplete verifies the chain at the start of the leaf validity, so an expired certificate is still inspected.
+// Self signed certificates of the bundle are trusted as roots.
+func chainComplete(chain []*x509.Certificate) bool {
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+
+	if selfSigned(chain[0]) {
+		return true
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, c := range chain {
+		if selfSigned(c) {
+			roots.AddCert(c)
+			continue
+		}
+		intermediates.AddCert(c)
+	}
+
+	_, err = chain[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   chain[0].NotBefore,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+
+	return err == nil
+}
+
+func selfSigned(c *x509.Certificate) bool {
+	return bytes.Equal(c.RawIssuer, c.RawSubject) && c.CheckSignature(c.SignatureAlgorithm, c.RawTBSCertificate, c.Signature) == nil
+}
+
+// ValidateKeyPair checks that crt and key are PEM encoded and that the key belongs to the certificate.
+func ValidateKeyPair(crt, key []byte) error {
+	if _, err := tls.X509KeyPair(crt, key); err != nil {
+		return err
+	}
+
+	_, err := ParseCertificate(crt)
+
+	return err
+}
+
+// GenerateSelfSigned returns a PEM encoded self signed certificate for commonName and its key.
+func GenerateSelfSigned(commonName string, validFor time.Duration) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	tpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: commonName, Organization: []string{"ingress-nginx-kubebuilder"}},
+		DNSNames:              []string{commonName},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(validFor),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), nil
+}
@@ -49,3 +49,8 @@ func (c Command) Output() ([]byte, error) {
 
 	return out, nil
 }
+
+// CombinedOutput returns the stdout and stderr of the command.
+func (c Command) CombinedOutput() ([]byte, error) {
+	return exec.Command(c.name, c.args...).CombinedOutput()
+}
@@ -36,7 +36,7 @@ func (w *WatcherFile) watch() error {
 				if !ok {
 					return
 				}
-				if w.dir == config.ConfDir && event.Has(fsnotify.Remove) {
+				if (w.dir == config.ConfDir || w.dir == config.StreamConfDir || w.dir == config.AcmeConfDir) && event.Has(fsnotify.Remove) {
 					w.onEvent()
 				} else if w.dir == config.SslPath && (event.Has(fsnotify.Write) || event.Has(fsnotify.Create)) {
 					w.onEvent()
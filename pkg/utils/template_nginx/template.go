@@ -3,6 +3,7 @@ package template_nginx
 import (
 	"bytes"
 	"fmt"
+	"github.com/ingoxx/ingress-nginx-kubebuilder/internal/config"
 	"k8s.io/klog/v2"
 	"os"
 	"text/template"
@@ -54,7 +55,7 @@ func (rt *RenderTemplate) Render(data interface{}) error {
 	}
 
 	var mainTpl bytes.Buffer
-	if err = mainTmpl.Execute(&mainTpl, nil); err != nil {
+	if err = mainTmpl.Execute(&mainTpl, config.NewMain()); err != nil {
 		klog.ErrorS(err, fmt.Sprintf("rendering %s template_nginx failed", rt.RenderTemplateName))
 		return err
 	}